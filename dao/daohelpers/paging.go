@@ -20,3 +20,18 @@ type Sorting[R any, T any] interface {
 	// WithSort sorts the items by certain pre-specified fields.
 	WithSort(xs ...T) R
 }
+
+// CursorPaging is the keyset variant of Paging. Cursors are opaque strings returned by a previous query.
+type CursorPaging[R any] interface {
+	// WithLimit should introduce a limit to the DAO results.
+	WithLimit(limit uint64) R
+
+	// GetLimit returns the set limit
+	GetLimit() uint64
+
+	// WithCursor should restrict the DAO results to those after (or before) the cursor.
+	WithCursor(cursor string) R
+
+	// GetCursor returns the set cursor.
+	GetCursor() string
+}
@@ -0,0 +1,450 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"google.golang.org/api/iterator"
+
+	dbutil "github.com/monstercat/golib/db"
+)
+
+// PostgresMaxParameters is the maximum number of parameters allowed in a single postgres statement.
+const PostgresMaxParameters = 65535
+
+var (
+	ErrBulkNoRows              = errors.New("no rows")
+	ErrBulkInconsistentColumns = errors.New("rows do not have the same columns")
+	ErrBulkMissingId           = errors.New("row is missing the id column")
+	ErrBulkMissingIdColumn     = errors.New("missing id column name")
+	ErrBulkNoUpdateColumns     = errors.New("rows do not have any columns to update")
+	ErrBulkIdMismatch          = errors.New("number of ids returned does not match the rows")
+)
+
+const (
+	// bulkAlias is the alias of the VALUES list in bulk queries.
+	bulkAlias = "_bulk"
+
+	// bulkOrdinal is the column of the VALUES list containing the index of the row, used to insert in order.
+	bulkOrdinal = "_ord"
+)
+
+// BulkUpdater inserts or updates many rows using as few statements as possible. Rows are split into batches so that
+// each statement stays under PostgresMaxParameters. The parameter T is the type for the ID which is returned through
+// the insert.
+//
+// Batches are not executed in a transaction. Use a transaction as the Provider if the rows should be inserted or
+// updated atomically.
+type BulkUpdater[T any] struct {
+	// Table to insert into or update.
+	Table string
+
+	// Name of the id column. Required for Insert and Update.
+	IdColumnName string
+
+	// What provides the DB connection for calling the functions.
+	Provider DBProvider
+
+	// BatchSize is the maximum number of rows per statement. If zero, or if it would exceed PostgresMaxParameters, the
+	// maximum number of rows allowed by PostgresMaxParameters is used.
+	BatchSize int
+
+	// PreprocessInsert preprocesses each insert query.
+	PreprocessInsert Preprocessor[squirrel.InsertBuilder]
+
+	// Rows to insert or update.
+	Rows []map[string]interface{}
+}
+
+func NewBulkUpdater[T any](table string) *BulkUpdater[T] {
+	return &BulkUpdater[T]{
+		Table: table,
+	}
+}
+
+func (b *BulkUpdater[T]) SetProvider(db DBProvider) *BulkUpdater[T] {
+	b.Provider = db
+	return b
+}
+
+func (b *BulkUpdater[T]) SetIdColumn(col string) *BulkUpdater[T] {
+	b.IdColumnName = col
+	return b
+}
+
+func (b *BulkUpdater[T]) SetBatchSize(size int) *BulkUpdater[T] {
+	b.BatchSize = size
+	return b
+}
+
+// Add adds a row.
+func (b *BulkUpdater[T]) Add(row map[string]interface{}) *BulkUpdater[T] {
+	b.Rows = append(b.Rows, row)
+	return b
+}
+
+// AddStruct adds a row generated from the struct through dbutil.SetMap.
+func (b *BulkUpdater[T]) AddStruct(val interface{}, isInsert bool) *BulkUpdater[T] {
+	return b.Add(dbutil.SetMap(val, isInsert))
+}
+
+// columns returns the union of the columns of all rows, sorted so that the query is stable.
+func (b *BulkUpdater[T]) columns() []string {
+	m := make(map[string]bool)
+	for _, row := range b.Rows {
+		for col := range row {
+			m[col] = true
+		}
+	}
+	cols := make([]string, 0, len(m))
+	for col := range m {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+	return cols
+}
+
+// batches splits the rows into batches based on the number of parameters per row.
+func (b *BulkUpdater[T]) batches(rows []map[string]interface{}, paramsPerRow int) [][]map[string]interface{} {
+	size := len(rows)
+	if paramsPerRow > 0 {
+		size = PostgresMaxParameters / paramsPerRow
+	}
+	if b.BatchSize > 0 && b.BatchSize < size {
+		size = b.BatchSize
+	}
+	if size < 1 {
+		size = 1
+	}
+
+	var batches [][]map[string]interface{}
+	for i := 0; i < len(rows); i += size {
+		end := i + size
+		if end > len(rows) {
+			end = len(rows)
+		}
+		batches = append(batches, rows[i:end])
+	}
+	return batches
+}
+
+// bulkInsertGroup contains the rows which have the same columns, along with the index of each row in Rows.
+type bulkInsertGroup struct {
+	cols []string
+	rows []map[string]interface{}
+	idx  []int
+}
+
+// insertGroups groups the rows by their columns, in order of first appearance. Rows within a group keep their order.
+func (b *BulkUpdater[T]) insertGroups() []*bulkInsertGroup {
+	var groups []*bulkInsertGroup
+	m := make(map[string]*bulkInsertGroup)
+	for i, row := range b.Rows {
+		cols := make([]string, 0, len(row))
+		for col := range row {
+			cols = append(cols, col)
+		}
+		sort.Strings(cols)
+
+		key := strings.Join(cols, ",")
+		g, ok := m[key]
+		if !ok {
+			g = &bulkInsertGroup{cols: cols}
+			m[key] = g
+			groups = append(groups, g)
+		}
+		g.rows = append(g.rows, row)
+		g.idx = append(g.idx, i)
+	}
+	return groups
+}
+
+// bulkInsertBatch is a single insert query along with the index in Rows of each row it inserts, in order.
+type bulkInsertBatch struct {
+	qry squirrel.InsertBuilder
+	idx []int
+}
+
+// insertBatches returns the insert queries for the rows. See InsertBuilders.
+func (b *BulkUpdater[T]) insertBatches() []bulkInsertBatch {
+	var batches []bulkInsertBatch
+	for _, g := range b.insertGroups() {
+		var offset int
+		for _, batch := range b.batches(g.rows, len(g.cols)) {
+			values := bulkValues(b.Table, g.cols, batch, true)
+			qry := squirrel.Insert(b.Table).
+				Columns(g.cols...).
+				Select(squirrel.Select(g.cols...).
+					FromSelect(values, bulkValuesAlias(g.cols, true)).
+					OrderBy(bulkAlias + "." + bulkOrdinal))
+			if b.PreprocessInsert != nil {
+				qry = b.PreprocessInsert(b.Table, qry)
+			}
+			batches = append(batches, bulkInsertBatch{
+				qry: qry,
+				idx: g.idx[offset : offset+len(batch)],
+			})
+			offset += len(batch)
+		}
+	}
+	return batches
+}
+
+// InsertBuilders returns the insert queries for the rows. Rows are grouped by their columns, so that missing columns
+// are set to their defaults, and each group is split into batches. The rows are selected from a VALUES list (see
+// bulkValues) ordered by their index, so that they are inserted in order, e.g.,
+//
+//	INSERT INTO table (a,b) SELECT a, b FROM ((SELECT a, b, 0 FROM table LIMIT 0) UNION ALL
+//	VALUES (?, ?, 0), (?, ?, 1)) AS _bulk(a, b, _ord) ORDER BY _bulk._ord
+func (b *BulkUpdater[T]) InsertBuilders() []squirrel.InsertBuilder {
+	batches := b.insertBatches()
+	qrys := make([]squirrel.InsertBuilder, 0, len(batches))
+	for _, batch := range batches {
+		qrys = append(qrys, batch.qry)
+	}
+	return qrys
+}
+
+// Insert inserts all rows and returns their IDs in the order of the rows. If a query returns a different number of
+// IDs than the rows it inserts (e.g., through ON CONFLICT DO NOTHING in PreprocessInsert), the IDs cannot be matched
+// to the rows and ErrBulkIdMismatch is returned.
+func (b *BulkUpdater[T]) Insert() ([]T, error) {
+	return b.InsertContext(context.Background())
+}
+
+// InsertContext is Insert with a context.
+func (b *BulkUpdater[T]) InsertContext(ctx context.Context) ([]T, error) {
+	if b.Provider == nil {
+		return nil, ErrMissingProvider
+	}
+	if b.IdColumnName == "" {
+		return nil, ErrBulkMissingIdColumn
+	}
+	if len(b.Rows) == 0 {
+		return nil, ErrBulkNoRows
+	}
+
+	ids := make([]T, len(b.Rows))
+	for _, batch := range b.insertBatches() {
+		rows, err := batch.qry.
+			PlaceholderFormat(squirrel.Dollar).
+			Suffix("RETURNING " + b.IdColumnName).
+			RunWith(contextRunner(b.Provider)).
+			QueryContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		it := &SelectIterator[T]{
+			Rows: rows,
+			Fn:   ScannerFunc[T](SingleColumnScanner[T]),
+			Ctx:  ctx,
+		}
+		batchIds := make([]T, 0, len(batch.idx))
+		if err := appendAll(it, &batchIds); err != nil {
+			return nil, err
+		}
+		if len(batchIds) != len(batch.idx) {
+			return nil, ErrBulkIdMismatch
+		}
+		for i, id := range batchIds {
+			ids[batch.idx[i]] = id
+		}
+	}
+	return ids, nil
+}
+
+// InsertNoId inserts all rows.
+func (b *BulkUpdater[T]) InsertNoId() error {
+	return b.InsertNoIdContext(context.Background())
+}
+
+// InsertNoIdContext is InsertNoId with a context.
+func (b *BulkUpdater[T]) InsertNoIdContext(ctx context.Context) error {
+	if b.Provider == nil {
+		return ErrMissingProvider
+	}
+	if len(b.Rows) == 0 {
+		return ErrBulkNoRows
+	}
+	for _, qry := range b.InsertBuilders() {
+		_, err := qry.
+			PlaceholderFormat(squirrel.Dollar).
+			RunWith(contextRunner(b.Provider)).
+			ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateBuilders returns the update queries for the rows, one per batch. Each row must contain the same columns,
+// including IdColumnName, which is used to match the rows, and at least one other column to update. The values are
+// typed through the table (see bulkValues), e.g.,
+//
+//	UPDATE table SET a = _bulk.a FROM ((SELECT a, id FROM table LIMIT 0) UNION ALL VALUES (?, ?), (?, ?))
+//	AS _bulk(a, id) WHERE table.id = _bulk.id
+func (b *BulkUpdater[T]) UpdateBuilders() ([]squirrel.Sqlizer, error) {
+	if b.IdColumnName == "" {
+		return nil, ErrBulkMissingIdColumn
+	}
+	if len(b.Rows) == 0 {
+		return nil, ErrBulkNoRows
+	}
+	cols := b.columns()
+	for _, row := range b.Rows {
+		if len(row) != len(cols) {
+			return nil, ErrBulkInconsistentColumns
+		}
+		if _, ok := row[b.IdColumnName]; !ok {
+			return nil, ErrBulkMissingId
+		}
+	}
+	if len(cols) < 2 {
+		return nil, ErrBulkNoUpdateColumns
+	}
+
+	batches := b.batches(b.Rows, len(cols))
+	qrys := make([]squirrel.Sqlizer, 0, len(batches))
+	for _, batch := range batches {
+		qrys = append(qrys, &bulkUpdateSqlizer{
+			table:  b.Table,
+			id:     b.IdColumnName,
+			cols:   cols,
+			values: batch,
+		})
+	}
+	return qrys, nil
+}
+
+// Update updates all rows, matching them by IdColumnName. It returns the number of rows updated.
+func (b *BulkUpdater[T]) Update() (int64, error) {
+	return b.UpdateContext(context.Background())
+}
+
+// UpdateContext is Update with a context.
+func (b *BulkUpdater[T]) UpdateContext(ctx context.Context) (int64, error) {
+	if b.Provider == nil {
+		return 0, ErrMissingProvider
+	}
+	qrys, err := b.UpdateBuilders()
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	runner := contextRunner(b.Provider).(squirrel.ExecerContext)
+	for _, qry := range qrys {
+		res, err := squirrel.ExecContextWith(ctx, runner, dollarSqlizer{qry})
+		if err != nil {
+			return total, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// bulkUpdateSqlizer generates UPDATE ... FROM (VALUES ...).
+type bulkUpdateSqlizer struct {
+	table  string
+	id     string
+	cols   []string
+	values []map[string]interface{}
+}
+
+func (s *bulkUpdateSqlizer) ToSql() (string, []interface{}, error) {
+	values, args, err := bulkValues(s.table, s.cols, s.values, false).ToSql()
+	if err != nil {
+		return "", nil, err
+	}
+
+	sets := make([]string, 0, len(s.cols)-1)
+	for _, col := range s.cols {
+		if col != s.id {
+			sets = append(sets, col+" = "+bulkAlias+"."+col)
+		}
+	}
+	sql := "UPDATE " + s.table + " SET " + strings.Join(sets, ", ") +
+		" FROM (" + values + ") AS " + bulkValuesAlias(s.cols, false) +
+		" WHERE " + s.table + "." + s.id + " = " + bulkAlias + "." + s.id
+	return sql, args, nil
+}
+
+// bulkValues returns the rows as a VALUES list of the columns. Postgres types the columns of a VALUES list as text
+// unless they are cast, which fails for other column types (e.g., uuid = text). Thus, the list is typed through a
+// UNION with an empty select of the columns from the table. If ordinal is true, the index of each row is added as the
+// bulkOrdinal column.
+func bulkValues(table string, cols []string, rows []map[string]interface{}, ordinal bool) squirrel.SelectBuilder {
+	typed := cols
+	if ordinal {
+		typed = append(append(make([]string, 0, len(cols)+1), cols...), "0")
+	}
+
+	placeholders := make([]string, len(cols))
+	for i := range placeholders {
+		placeholders[i] = "?"
+	}
+	values := make([]string, 0, len(rows))
+	args := make([]interface{}, 0, len(rows)*len(cols))
+	for i, row := range rows {
+		v := strings.Join(placeholders, ", ")
+		if ordinal {
+			v += ", " + strconv.Itoa(i)
+		}
+		values = append(values, "("+v+")")
+		for _, col := range cols {
+			args = append(args, row[col])
+		}
+	}
+
+	return squirrel.Select(typed...).
+		From(table).
+		Limit(0).
+		Prefix("(").
+		Suffix(") UNION ALL VALUES "+strings.Join(values, ", "), args...)
+}
+
+// bulkValuesAlias returns the alias of the VALUES list, naming its columns.
+func bulkValuesAlias(cols []string, ordinal bool) string {
+	if ordinal {
+		cols = append(append(make([]string, 0, len(cols)+1), cols...), bulkOrdinal)
+	}
+	return bulkAlias + "(" + strings.Join(cols, ", ") + ")"
+}
+
+// dollarSqlizer replaces the placeholders of the underlying sqlizer with squirrel.Dollar.
+type dollarSqlizer struct {
+	squirrel.Sqlizer
+}
+
+func (s dollarSqlizer) ToSql() (string, []interface{}, error) {
+	sql, args, err := s.Sqlizer.ToSql()
+	if err != nil {
+		return "", nil, err
+	}
+	sql, err = squirrel.Dollar.ReplacePlaceholders(sql)
+	return sql, args, err
+}
+
+// appendAll appends all remaining objects of the iterator to xs. The rows are closed.
+func appendAll[R any](it *SelectIterator[R], xs *[]R) error {
+	defer it.Close()
+	for {
+		obj, err := it.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		*xs = append(*xs, obj)
+	}
+}
@@ -0,0 +1,160 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkUpdater_InsertBuilders(t *testing.T) {
+	b := NewBulkUpdater[string]("track").SetBatchSize(2)
+	b.Add(map[string]interface{}{"title": "a", "plays": 1})
+	b.Add(map[string]interface{}{"title": "b"})
+	b.Add(map[string]interface{}{"title": "c", "plays": 3})
+
+	// Rows are grouped by their columns.
+	qrys := b.InsertBuilders()
+	require.Len(t, qrys, 2)
+
+	sql, args, err := qrys[0].PlaceholderFormat(squirrel.Dollar).ToSql()
+	require.NoError(t, err)
+	assert.Equal(t, "INSERT INTO track (plays,title) SELECT plays, title "+
+		"FROM (( SELECT plays, title, 0 FROM track LIMIT 0 ) UNION ALL VALUES ($1, $2, 0), ($3, $4, 1)) "+
+		"AS _bulk(plays, title, _ord) ORDER BY _bulk._ord", sql)
+	assert.Equal(t, []interface{}{1, "a", 3, "c"}, args)
+
+	sql, args, err = qrys[1].PlaceholderFormat(squirrel.Dollar).ToSql()
+	require.NoError(t, err)
+	assert.Equal(t, "INSERT INTO track (title) SELECT title "+
+		"FROM (( SELECT title, 0 FROM track LIMIT 0 ) UNION ALL VALUES ($1, 0)) "+
+		"AS _bulk(title, _ord) ORDER BY _bulk._ord", sql)
+	assert.Equal(t, []interface{}{"b"}, args)
+
+	// The batch size is capped by the parameter limit.
+	b = NewBulkUpdater[string]("track")
+	for i := 0; i < PostgresMaxParameters; i++ {
+		b.Add(map[string]interface{}{"title": "a", "plays": i})
+	}
+	assert.Len(t, b.InsertBuilders(), 3)
+}
+
+func TestBulkUpdater_InsertOrder(t *testing.T) {
+	// Each statement returns the ids of its rows in order.
+	db := &returningDb{ids: [][]string{{"a", "c"}, {"b", "d"}}}
+	b := NewBulkUpdater[string]("track").SetProvider(db).SetIdColumn("id")
+	b.Add(map[string]interface{}{"title": "a", "plays": 1})
+	b.Add(map[string]interface{}{"title": "b"})
+	b.Add(map[string]interface{}{"title": "c", "plays": 3})
+	b.Add(map[string]interface{}{"title": "d"})
+
+	ids, err := b.Insert()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, ids)
+	require.Len(t, db.queries, 2)
+	assert.Contains(t, db.queries[0], "ORDER BY _bulk._ord RETURNING id")
+
+	// The ids cannot be matched if rows are skipped.
+	db = &returningDb{ids: [][]string{{"a"}}}
+	b.Provider = db
+	b.Rows = b.Rows[:1]
+	b.Add(map[string]interface{}{"title": "c", "plays": 3})
+	_, err = b.Insert()
+	assert.ErrorIs(t, err, ErrBulkIdMismatch)
+}
+
+func TestBulkUpdater_UpdateBuilders(t *testing.T) {
+	b := NewBulkUpdater[string]("track").SetIdColumn("id")
+	b.Add(map[string]interface{}{"id": 1, "title": "a", "plays": 1})
+	b.Add(map[string]interface{}{"id": 2, "title": "b", "plays": 2})
+
+	qrys, err := b.UpdateBuilders()
+	require.NoError(t, err)
+	require.Len(t, qrys, 1)
+
+	sql, args, err := dollarSqlizer{qrys[0]}.ToSql()
+	require.NoError(t, err)
+	// The values are typed through the table, so that the int id is not compared as text.
+	assert.Equal(t, "UPDATE track SET plays = _bulk.plays, title = _bulk.title "+
+		"FROM (( SELECT id, plays, title FROM track LIMIT 0 ) UNION ALL VALUES ($1, $2, $3), ($4, $5, $6)) "+
+		"AS _bulk(id, plays, title) WHERE track.id = _bulk.id", sql)
+	assert.Equal(t, []interface{}{1, 1, "a", 2, 2, "b"}, args)
+
+	b.Add(map[string]interface{}{"id": "3", "title": "c"})
+	_, err = b.UpdateBuilders()
+	assert.ErrorIs(t, err, ErrBulkInconsistentColumns)
+
+	b.Rows = []map[string]interface{}{{"title": "c"}}
+	_, err = b.UpdateBuilders()
+	assert.ErrorIs(t, err, ErrBulkMissingId)
+
+	b.Rows = []map[string]interface{}{{"id": "1"}, {"id": "2"}}
+	_, err = b.UpdateBuilders()
+	assert.ErrorIs(t, err, ErrBulkNoUpdateColumns)
+
+	b.IdColumnName = ""
+	_, err = b.UpdateBuilders()
+	assert.ErrorIs(t, err, ErrBulkMissingIdColumn)
+}
+
+func TestBulkUpdater_InsertMissingIdColumn(t *testing.T) {
+	p := &ctxProvider{db: &ctxDb{}}
+	b := NewBulkUpdater[string]("track").SetProvider(p)
+	b.Add(map[string]interface{}{"title": "a"})
+	_, err := b.Insert()
+	assert.ErrorIs(t, err, ErrBulkMissingIdColumn)
+
+	// No query should have been run.
+	assert.Empty(t, p.db.queries)
+}
+
+// returningDb returns the next list of ids for each query.
+type returningDb struct {
+	ids     [][]string
+	queries []string
+	db      *sqlx.DB
+}
+
+func (d *returningDb) GetDb() sqlx.Ext { return nil }
+
+func (d *returningDb) GetDbContext() sqlx.ExtContext {
+	if d.db == nil {
+		d.db = sqlx.NewDb(sql.OpenDB(d), "postgres")
+	}
+	return d.db
+}
+
+func (d *returningDb) Connect(context.Context) (driver.Conn, error) { return d, nil }
+func (d *returningDb) Driver() driver.Driver                        { return d }
+func (d *returningDb) Open(string) (driver.Conn, error)             { return d, nil }
+func (d *returningDb) Prepare(string) (driver.Stmt, error)          { return nil, driver.ErrSkip }
+func (d *returningDb) Close() error                                 { return nil }
+func (d *returningDb) Begin() (driver.Tx, error)                    { return nil, driver.ErrSkip }
+
+func (d *returningDb) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	d.queries = append(d.queries, query)
+	ids := d.ids[0]
+	d.ids = d.ids[1:]
+	return &returningRows{ids: ids}, nil
+}
+
+type returningRows struct {
+	ids []string
+}
+
+func (r *returningRows) Columns() []string { return []string{"id"} }
+func (r *returningRows) Close() error      { return nil }
+
+func (r *returningRows) Next(dest []driver.Value) error {
+	if len(r.ids) == 0 {
+		return io.EOF
+	}
+	dest[0], r.ids = r.ids[0], r.ids[1:]
+	return nil
+}
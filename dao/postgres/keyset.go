@@ -0,0 +1,151 @@
+package postgres
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+
+	pgutil "github.com/monstercat/golib/db/postgres"
+	"github.com/monstercat/golib/page"
+)
+
+var (
+	ErrKeysetMissingColumns = errors.New("keyset columns are missing")
+	ErrKeysetMissingValues  = errors.New("keyset values function is missing")
+	ErrKeysetUnknownColumn  = errors.New("sort does not have a known column")
+	ErrKeysetCursorMismatch = errors.New("cursor does not match the keyset")
+)
+
+// KeysetColumn is a column used for keyset pagination. The columns, in order, must be unique for each row and must
+// not be NULL; the last column is normally the primary key.
+type KeysetColumn struct {
+	// Column to compare. TablePlaceholder is replaced with the From table.
+	Column string
+
+	// Desc is true if the column is sorted in descending order.
+	Desc bool
+}
+
+// KeysetFromSorts converts sorts resolved by pgutil.SortSpec into keyset columns. SortSpec.Tiebreaker should be set so
+// that the keyset is unique. ErrKeysetUnknownColumn is returned if any sort does not map directly onto a column.
+func KeysetFromSorts(sorts []pgutil.ActiveSort) ([]KeysetColumn, error) {
+	cols := make([]KeysetColumn, 0, len(sorts))
+	for _, s := range sorts {
+		col, ok := s.Column()
+		if !ok {
+			return nil, ErrKeysetUnknownColumn
+		}
+		if _, ok := s.Sort.(pgutil.ExtendedSort); ok {
+			return nil, ErrKeysetUnknownColumn
+		}
+		cols = append(cols, KeysetColumn{
+			Column: col,
+			Desc:   s.Direction() == pgutil.Desc,
+		})
+	}
+	return cols, nil
+}
+
+// KeysetPage contains the cursors for the pages adjacent to the one returned. An empty cursor means there is no such
+// page.
+type KeysetPage struct {
+	Next     string
+	Previous string
+}
+
+// Apply sets the cursors and count on the page.
+func (p KeysetPage) Apply(pg *page.Page, count int) {
+	pg.Count = count
+	pg.NextCursor = p.Next
+	pg.PreviousCursor = p.Previous
+}
+
+// keysetSort returns the identifier for the keyset stored in the cursor.
+func keysetSort(cols []KeysetColumn) string {
+	xs := make([]string, 0, len(cols))
+	for _, c := range cols {
+		if c.Desc {
+			xs = append(xs, "-"+c.Column)
+		} else {
+			xs = append(xs, c.Column)
+		}
+	}
+	return strings.Join(xs, ",")
+}
+
+// keysetOrderBy returns the order by statements for the keyset. If backward, the directions are reversed.
+func keysetOrderBy(cols []KeysetColumn, backward bool) []string {
+	xs := make([]string, 0, len(cols))
+	for _, c := range cols {
+		if c.Desc != backward {
+			xs = append(xs, c.Column+" DESC")
+		} else {
+			xs = append(xs, c.Column+" ASC")
+		}
+	}
+	return xs
+}
+
+// keysetCondition returns the condition which selects the rows after the values in the order of the keyset (or
+// before, if backward). If all columns are sorted in the same direction, a row comparison is used so that a
+// multi-column index can be used, e.g., (a, b) > (?, ?). Otherwise, the comparison is expanded.
+func keysetCondition(cols []KeysetColumn, values []interface{}, backward bool) squirrel.Sqlizer {
+	op := func(c KeysetColumn) string {
+		if c.Desc != backward {
+			return "<"
+		}
+		return ">"
+	}
+
+	sameDirection := true
+	for _, c := range cols[1:] {
+		if c.Desc != cols[0].Desc {
+			sameDirection = false
+			break
+		}
+	}
+	if sameDirection {
+		names := make([]string, 0, len(cols))
+		placeholders := make([]string, 0, len(cols))
+		for _, c := range cols {
+			names = append(names, c.Column)
+			placeholders = append(placeholders, "?")
+		}
+		sql := "(" + strings.Join(names, ", ") + ") " + op(cols[0]) + " (" + strings.Join(placeholders, ", ") + ")"
+		return squirrel.Expr(sql, values...)
+	}
+
+	// (a > ?) OR (a = ? AND b < ?) OR ...
+	or := make(squirrel.Or, 0, len(cols))
+	for i, c := range cols {
+		and := make(squirrel.And, 0, i+1)
+		for j := 0; j < i; j++ {
+			and = append(and, squirrel.Expr(cols[j].Column+" = ?", values[j]))
+		}
+		and = append(and, squirrel.Expr(c.Column+" "+op(c)+" ?", values[i]))
+		or = append(or, and)
+	}
+	return or
+}
+
+// encodeKeysetCursor creates the cursor for the provided values.
+func encodeKeysetCursor(cols []KeysetColumn, values []interface{}, backward bool) (string, error) {
+	return page.EncodeCursor(page.Cursor{
+		Values:   values,
+		Backward: backward,
+		Sort:     keysetSort(cols),
+	})
+}
+
+// decodeKeysetCursor decodes the cursor, ensuring that it was created with the same keyset.
+func decodeKeysetCursor(cols []KeysetColumn, cursor string) (page.Cursor, error) {
+	c, err := page.DecodeCursor(cursor)
+	if err != nil {
+		return c, err
+	}
+	if c.Sort != keysetSort(cols) || len(c.Values) != len(cols) {
+		return c, ErrKeysetCursorMismatch
+	}
+	return c, nil
+}
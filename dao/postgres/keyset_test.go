@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/monstercat/golib/dao/daohelpers"
+	pgutil "github.com/monstercat/golib/db/postgres"
+)
+
+func TestKeysetCondition(t *testing.T) {
+	tests := []struct {
+		cols     []KeysetColumn
+		backward bool
+		sql      string
+		order    []string
+	}{
+		{
+			cols:  []KeysetColumn{{Column: "t.title"}, {Column: "t.id"}},
+			sql:   "(t.title, t.id) > (?, ?)",
+			order: []string{"t.title ASC", "t.id ASC"},
+		},
+		{
+			cols:     []KeysetColumn{{Column: "t.title", Desc: true}, {Column: "t.id", Desc: true}},
+			backward: true,
+			sql:      "(t.title, t.id) > (?, ?)",
+			order:    []string{"t.title ASC", "t.id ASC"},
+		},
+		{
+			cols:  []KeysetColumn{{Column: "t.title"}, {Column: "t.id", Desc: true}},
+			sql:   "((t.title > ?) OR (t.title = ? AND t.id < ?))",
+			order: []string{"t.title ASC", "t.id DESC"},
+		},
+	}
+
+	for i, test := range tests {
+		sql, _, err := keysetCondition(test.cols, []interface{}{"a", 1}, test.backward).ToSql()
+		require.NoError(t, err, "[%d]", i)
+		assert.Equal(t, test.sql, sql, "[%d]", i)
+		assert.Equal(t, test.order, keysetOrderBy(test.cols, test.backward), "[%d]", i)
+	}
+
+	// Check the arguments of the expanded form.
+	_, args, err := squirrel.Select("*").From("t").
+		Where(keysetCondition(tests[2].cols, []interface{}{"a", 1}, false)).
+		ToSql()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", "a", 1}, args)
+}
+
+func TestKeysetCursor(t *testing.T) {
+	cols := []KeysetColumn{{Column: "t.plays", Desc: true}, {Column: "t.id"}}
+
+	str, err := encodeKeysetCursor(cols, []interface{}{int64(9007199254740993), "abc"}, true)
+	require.NoError(t, err)
+
+	c, err := decodeKeysetCursor(cols, str)
+	require.NoError(t, err)
+	assert.True(t, c.Backward)
+	require.Len(t, c.Values, 2)
+	assert.Equal(t, "9007199254740993", c.Values[0].(interface{ String() string }).String())
+	assert.Equal(t, "abc", c.Values[1])
+
+	_, err = decodeKeysetCursor([]KeysetColumn{{Column: "t.plays"}, {Column: "t.id"}}, str)
+	assert.ErrorIs(t, err, ErrKeysetCursorMismatch)
+}
+
+func TestKeysetFromSorts(t *testing.T) {
+	spec := &pgutil.SortSpec{
+		Sorts: map[string]pgutil.Sort{
+			"title": pgutil.SimpleSort("t.title"),
+			"other": pgutil.ExtendedSort{Field: "t.other", SecondarySorts: []string{"t.title"}},
+		},
+		Tiebreaker: "t.id",
+	}
+
+	sorts, err := spec.Resolve([]string{"-title"})
+	require.NoError(t, err)
+	cols, err := KeysetFromSorts(sorts)
+	require.NoError(t, err)
+	assert.Equal(t, []KeysetColumn{{Column: "t.title", Desc: true}, {Column: "t.id", Desc: true}}, cols)
+
+	sorts, err = spec.Resolve([]string{"other"})
+	require.NoError(t, err)
+	_, err = KeysetFromSorts(sorts)
+	assert.ErrorIs(t, err, ErrKeysetUnknownColumn)
+}
+
+func TestSelector_CursorPaging(t *testing.T) {
+	s := &Selector[string]{QueryBuilder: NewSelectBuilder(NewStatementBuilder())}
+	var paging daohelpers.CursorPaging[*Selector[string]] = s
+
+	assert.Same(t, s, paging.WithLimit(10).WithCursor("abc"))
+	assert.Equal(t, uint64(10), paging.GetLimit())
+	assert.Equal(t, "abc", paging.GetCursor())
+	assert.Equal(t, "abc", s.QueryBuilder.Cursor)
+}
@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+
+	dbutil "github.com/monstercat/golib/db"
+)
+
+// DBContextProvider is an optional interface for a DBProvider. If implemented, the connection it provides is used for
+// queries which take a context.Context.
+//
+// Providers which return a *sqlx.DB or *sqlx.Tx from GetDb do not need to implement it, as both already support
+// contexts.
+type DBContextProvider interface {
+	GetDbContext() sqlx.ExtContext
+}
+
+// DBErrorProvider is an optional interface for a DBProvider whose connection may be unavailable (e.g., a transaction
+// which failed to begin). If DbError returns an error, queries fail with it instead of running.
+type DBErrorProvider interface {
+	DbError() error
+}
+
+// contextRunner returns a squirrel runner for the provider which supports contexts. If the connection does not support
+// contexts, the context is ignored.
+func contextRunner(p DBProvider) squirrel.BaseRunner {
+	if ep, ok := p.(DBErrorProvider); ok {
+		if err := ep.DbError(); err != nil {
+			return &extRunner{err: err}
+		}
+	}
+	if cp, ok := p.(DBContextProvider); ok {
+		if db := cp.GetDbContext(); db != nil {
+			return &extRunner{ctxDb: db}
+		}
+	}
+	db := p.GetDb()
+	r := &extRunner{db: db}
+	if ctxDb, ok := db.(sqlx.ExtContext); ok {
+		r.ctxDb = ctxDb
+	}
+	return r
+}
+
+// extRunner implements squirrel.RunnerContext for sqlx connections. The queries are instrumented through
+// dbutil.StartQuery.
+type extRunner struct {
+	db    sqlx.Ext
+	ctxDb sqlx.ExtContext
+
+	// err is returned by all queries if set.
+	err error
+}
+
+func (r *extRunner) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return r.ExecContext(context.Background(), query, args...)
+}
+
+func (r *extRunner) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return r.QueryContext(context.Background(), query, args...)
+}
+
+func (r *extRunner) QueryRow(query string, args ...interface{}) squirrel.RowScanner {
+	return r.QueryRowContext(context.Background(), query, args...)
+}
+
+func (r *extRunner) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	ctx, done := dbutil.StartQuery(ctx, dbutil.OperationExec, query, args)
+	res, err := r.exec(ctx, query, args...)
+	done(dbutil.ResultRowsAffected(res), err)
+	return res, err
+}
+
+func (r *extRunner) exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if r.ctxDb != nil {
+		return r.ctxDb.ExecContext(ctx, query, args...)
+	}
+	return r.db.Exec(query, args...)
+}
+
+func (r *extRunner) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	ctx, done := dbutil.StartQuery(ctx, dbutil.OperationQuery, query, args)
+	rows, err := r.query(ctx, query, args...)
+	done(-1, err)
+	return rows, err
+}
+
+func (r *extRunner) query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if r.ctxDb != nil {
+		return r.ctxDb.QueryContext(ctx, query, args...)
+	}
+	return r.db.Query(query, args...)
+}
+
+func (r *extRunner) QueryRowContext(ctx context.Context, query string, args ...interface{}) squirrel.RowScanner {
+	if r.err != nil {
+		return errRow{r.err}
+	}
+	ctx, done := dbutil.StartQuery(ctx, dbutil.OperationQueryRow, query, args)
+	return &dbutil.HookedRow{
+		Row:  r.queryRow(ctx, query, args...),
+		Done: done,
+	}
+}
+
+func (r *extRunner) queryRow(ctx context.Context, query string, args ...interface{}) squirrel.RowScanner {
+	if r.ctxDb != nil {
+		return r.ctxDb.QueryRowxContext(ctx, query, args...)
+	}
+	return r.db.QueryRowx(query, args...)
+}
+
+// errRow is a squirrel.RowScanner which fails with err.
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(...interface{}) error {
+	return r.err
+}
@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/monstercat/golib/dao/daohelpers"
+	dbutil "github.com/monstercat/golib/db"
+)
+
+// ctxDb records the queries it receives and fails with the error of the context.
+type ctxDb struct {
+	queries []string
+}
+
+func (d *ctxDb) DriverName() string                                               { return "postgres" }
+func (d *ctxDb) Rebind(s string) string                                           { return s }
+func (d *ctxDb) BindNamed(s string, _ interface{}) (string, []interface{}, error) { return s, nil, nil }
+
+func (d *ctxDb) QueryContext(ctx context.Context, query string, _ ...interface{}) (*sql.Rows, error) {
+	d.queries = append(d.queries, query)
+	return nil, ctx.Err()
+}
+
+func (d *ctxDb) QueryxContext(ctx context.Context, query string, _ ...interface{}) (*sqlx.Rows, error) {
+	d.queries = append(d.queries, query)
+	return nil, ctx.Err()
+}
+
+func (d *ctxDb) QueryRowxContext(ctx context.Context, query string, _ ...interface{}) *sqlx.Row {
+	d.queries = append(d.queries, query)
+	return &sqlx.Row{}
+}
+
+// ExecContext affects no rows.
+func (d *ctxDb) ExecContext(ctx context.Context, query string, _ ...interface{}) (sql.Result, error) {
+	d.queries = append(d.queries, query)
+	return driver.RowsAffected(0), ctx.Err()
+}
+
+type ctxProvider struct {
+	db *ctxDb
+}
+
+func (p *ctxProvider) GetDb() sqlx.Ext               { return nil }
+func (p *ctxProvider) GetDbContext() sqlx.ExtContext { return p.db }
+
+func TestContextRunner(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	p := &ctxProvider{db: &ctxDb{}}
+
+	u := NewUpdater[string](NewStatementBuilder(), "release").SetProvider(p)
+	u.Set("title", "hello")
+	u.QueryBuilder.AddCondition(squirrel.Eq{"id": "1"})
+	assert.ErrorIs(t, u.UpdateContext(ctx), context.Canceled)
+
+	s := &Selector[string]{
+		QueryBuilder: NewSelectBuilder(NewStatementBuilder()).SetFrom("release"),
+		Provider:     p,
+		GetCols:      []string{"title"},
+		Scanner:      ScannerFunc[string](SingleColumnScanner[string]),
+	}
+	_, err := s.SelectContext(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	assert.Equal(t, []string{
+		"UPDATE release SET title = $1 WHERE (id = $2)",
+		" SELECT title FROM release",
+	}, p.db.queries)
+}
+
+func TestContextRunner_Hooks(t *testing.T) {
+	defer dbutil.ClearQueryHooks()
+
+	var events []dbutil.QueryEvent
+	dbutil.AddQueryHook(dbutil.QueryHookFunc(func(_ context.Context, e *dbutil.QueryEvent) {
+		events = append(events, *e)
+	}))
+
+	p := &ctxProvider{db: &ctxDb{}}
+	u := NewUpdater[string](NewStatementBuilder(), "release").SetProvider(p)
+	u.Set("title", "hello")
+	u.QueryBuilder.AddCondition(squirrel.Eq{"id": "1"})
+	assert.ErrorIs(t, u.Update(), daohelpers.ErrNoUpdatePerformed)
+
+	assert.Len(t, events, 1)
+	assert.Equal(t, dbutil.OperationExec, events[0].Operation)
+	assert.Equal(t, "UPDATE release SET title = $1 WHERE (id = $2)", events[0].SQL)
+	assert.Equal(t, []interface{}{"hello", "1"}, events[0].Args)
+	assert.Equal(t, int64(0), events[0].RowsAffected)
+}
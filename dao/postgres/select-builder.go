@@ -32,7 +32,7 @@ func NewSelectBuilder(statement *StatementBuilder) *SelectBuilder {
 // Ensure to add paging etc and sort. Conditions are automatically applied.
 func (d *SelectBuilder) Builder(cols ...string) squirrel.SelectBuilder {
 	qry := squirrel.Select(cols...).From(d.From).
-		PrefixExpr(d.WithPrefix)
+		PrefixExpr(d.With())
 	d.ApplyConditions(&qry)
 	d.ApplyJoin(&qry)
 	return qry
@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"strings"
@@ -8,12 +9,16 @@ import (
 	"github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
 	"google.golang.org/api/iterator"
+
+	"github.com/monstercat/golib/dao/daohelpers"
 )
 
 var (
 	ErrSelectorMissingColumns = errors.New("columns are missing")
 )
 
+var _ daohelpers.CursorPaging[*Selector[any]] = (*Selector[any])(nil)
+
 // ScannerFunc is a definition for a function that performs scanning.
 type ScannerFunc[T any] func(scanner squirrel.RowScanner) (T, error)
 
@@ -75,17 +80,94 @@ type Selector[T any] struct {
 	// ProcessGetResult allows further processing as a result of the result
 	// of the get.
 	ProcessGetResult func(val interface{}, err error) error
+
+	// KeysetValues returns the values of the keyset columns (see StatementBuilder.Keyset) for the object. It is
+	// required by SelectKeyset to generate cursors.
+	KeysetValues func(T) []interface{}
+
+	// SoftDelete, if set, filters out soft deleted rows in Get, Select, Iterate, SelectKeyset, Total and Exists, unless
+	// IncludeDeleted is set.
+	SoftDelete *SoftDelete
+
+	// IncludeDeleted includes soft deleted rows.
+	IncludeDeleted bool
+}
+
+// SetSoftDelete enables filtering of soft deleted rows using the column. See SoftDelete.
+func (s *Selector[T]) SetSoftDelete(column string) *Selector[T] {
+	s.SoftDelete = NewSoftDelete(column)
+	return s
+}
+
+// SetIncludeDeleted sets whether soft deleted rows are included.
+func (s *Selector[T]) SetIncludeDeleted(include bool) *Selector[T] {
+	s.IncludeDeleted = include
+	return s
+}
+
+// WithLimit sets the Limit of the QueryBuilder. It implements daohelpers.CursorPaging.
+func (s *Selector[T]) WithLimit(limit uint64) *Selector[T] {
+	s.QueryBuilder.SetLimit(limit)
+	return s
+}
+
+// GetLimit returns the Limit of the QueryBuilder.
+func (s *Selector[T]) GetLimit() uint64 {
+	return s.QueryBuilder.GetLimit()
+}
+
+// WithCursor sets the Cursor of the QueryBuilder, which is used by SelectKeyset. It implements daohelpers.CursorPaging.
+func (s *Selector[T]) WithCursor(cursor string) *Selector[T] {
+	s.QueryBuilder.SetCursor(cursor)
+	return s
+}
+
+// GetCursor returns the Cursor of the QueryBuilder.
+func (s *Selector[T]) GetCursor() string {
+	return s.QueryBuilder.Cursor
+}
+
+// builder returns the select builder from the QueryBuilder, excluding soft deleted rows if required.
+func (s *Selector[T]) builder(cols ...string) squirrel.SelectBuilder {
+	qry := s.QueryBuilder.Builder(cols...)
+	if s.SoftDelete != nil && !s.IncludeDeleted {
+		qry = qry.Where(s.SoftDelete.Condition(s.QueryBuilder.From))
+	}
+	return qry
+}
+
+// SelectBuilder returns the query run by Select and Iterate for the columns. Soft deleted rows are excluded if
+// required, paging and sorting are applied, and the query is preprocessed through PreprocessSelect. If no columns are
+// provided, GetCols is used.
+func (s *Selector[T]) SelectBuilder(cols ...string) squirrel.SelectBuilder {
+	if len(cols) == 0 {
+		cols = s.processGetCols()
+	}
+	qry := s.builder(cols...)
+	s.QueryBuilder.ApplyPaging(&qry)
+	s.QueryBuilder.ApplySort(&qry)
+
+	// Add preprocessor
+	if s.PreprocessSelect != nil {
+		qry = s.PreprocessSelect(s.QueryBuilder.From, qry)
+	}
+	return qry
 }
 
 // Get returns a single object that satisfies the query, up to a certain Limit. It handles sorting but paging is
 // unncessary as the first object will always be the one that is returned. If no columns are provided,
 // ErrSelectorMissingColumns is returned.
 func (s *Selector[T]) Get() (T, error) {
+	return s.GetContext(context.Background())
+}
+
+// GetContext is Get with a context.
+func (s *Selector[T]) GetContext(ctx context.Context) (T, error) {
 	if len(s.GetCols) == 0 {
 		var t T
 		return t, ErrSelectorMissingColumns
 	}
-	return Get[T](s, s.Scanner, s.processGetCols()...)
+	return GetContext[T](ctx, s, s.Scanner, s.processGetCols()...)
 }
 
 func (s *Selector[T]) processGetCols() []string {
@@ -98,9 +180,14 @@ func (s *Selector[T]) processGetCols() []string {
 
 // Get returns a single object with custom columns provided.
 func Get[T any](s *Selector[T], scanner Scanner[T], cols ...string) (T, error) {
-	qry := s.QueryBuilder.Builder(cols...).
+	return GetContext[T](context.Background(), s, scanner, cols...)
+}
+
+// GetContext is Get with a context.
+func GetContext[T any](ctx context.Context, s *Selector[T], scanner Scanner[T], cols ...string) (T, error) {
+	qry := s.builder(cols...).
 		PlaceholderFormat(squirrel.Dollar).
-		RunWith(s.Provider.GetDb())
+		RunWith(contextRunner(s.Provider))
 	s.QueryBuilder.ApplySort(&qry)
 
 	// Add preprocessor
@@ -114,23 +201,33 @@ func Get[T any](s *Selector[T], scanner Scanner[T], cols ...string) (T, error) {
 		}
 		return val, err
 	}
-	return fn(scanner.Scan(qry))
+	return fn(scanner.Scan(qry.QueryRowContext(ctx)))
 }
 
 // Select returns a list of objects that satisfies the query, up to a certain Limit. It also handles sorting
 // and Offset. Columns are required for the selector to function. If no columns are provided, ErrSelectorMissingColumns
 // is returned.
 func (s *Selector[T]) Select() ([]T, error) {
+	return s.SelectContext(context.Background())
+}
+
+// SelectContext is Select with a context.
+func (s *Selector[T]) SelectContext(ctx context.Context) ([]T, error) {
 	if len(s.GetCols) == 0 {
 		return nil, ErrSelectorMissingColumns
 	}
-	return Select[T](s, s.Scanner, s.processGetCols()...)
+	return SelectContext[T](ctx, s, s.Scanner, s.processGetCols()...)
 }
 
 // Select returns a list of objects with columns provided.
 // It uses the conditions / paging / sort in the provided
 // Selector to restrict the output.
 func Select[R any](s *Selector[R], scanner Scanner[R], cols ...string) ([]R, error) {
+	return SelectContext[R](context.Background(), s, scanner, cols...)
+}
+
+// SelectContext is Select with a context.
+func SelectContext[R any](ctx context.Context, s *Selector[R], scanner Scanner[R], cols ...string) ([]R, error) {
 	fn := func(val []R, err error) ([]R, error) {
 		if s.ProcessSelectResult != nil {
 			s.ProcessSelectResult(val, err)
@@ -138,10 +235,11 @@ func Select[R any](s *Selector[R], scanner Scanner[R], cols ...string) ([]R, err
 		return val, err
 	}
 
-	rows, err := Iterate(s, scanner, cols...)
+	rows, err := IterateContext(ctx, s, scanner, cols...)
 	if err != nil {
 		return fn(nil, err)
 	}
+	defer rows.Close()
 
 	var xs []R
 	for {
@@ -157,30 +255,164 @@ func Select[R any](s *Selector[R], scanner Scanner[R], cols ...string) ([]R, err
 	}
 }
 
+// SelectKeyset returns a list of objects using keyset pagination instead of Offset. The objects are ordered by the
+// Keyset columns in the QueryBuilder, starting after (or before) its Cursor. Cursors for the adjacent pages are
+// returned. Columns are required for the selector to function. If no columns are provided, ErrSelectorMissingColumns
+// is returned.
+func (s *Selector[T]) SelectKeyset() ([]T, KeysetPage, error) {
+	return s.SelectKeysetContext(context.Background())
+}
+
+// SelectKeysetContext is SelectKeyset with a context.
+func (s *Selector[T]) SelectKeysetContext(ctx context.Context) ([]T, KeysetPage, error) {
+	if len(s.GetCols) == 0 {
+		return nil, KeysetPage{}, ErrSelectorMissingColumns
+	}
+	return SelectKeysetContext[T](ctx, s, s.Scanner, s.processGetCols()...)
+}
+
+// SelectKeyset returns a list of objects with columns provided using keyset pagination. It uses the conditions,
+// Limit, Keyset and Cursor in the provided Selector to restrict the output. The Offset and sorts are ignored as the
+// order is defined by the Keyset.
+func SelectKeyset[R any](s *Selector[R], scanner Scanner[R], cols ...string) ([]R, KeysetPage, error) {
+	return SelectKeysetContext[R](context.Background(), s, scanner, cols...)
+}
+
+// SelectKeysetContext is SelectKeyset with a context.
+func SelectKeysetContext[R any](
+	ctx context.Context,
+	s *Selector[R],
+	scanner Scanner[R],
+	cols ...string,
+) ([]R, KeysetPage, error) {
+	var p KeysetPage
+	keyset := make([]KeysetColumn, 0, len(s.QueryBuilder.Keyset))
+	for _, c := range s.QueryBuilder.Keyset {
+		c.Column = strings.Replace(c.Column, TablePlaceholder, s.QueryBuilder.From, -1)
+		keyset = append(keyset, c)
+	}
+	if len(keyset) == 0 {
+		return nil, p, ErrKeysetMissingColumns
+	}
+	if s.KeysetValues == nil {
+		return nil, p, ErrKeysetMissingValues
+	}
+
+	qry := s.builder(cols...).
+		PlaceholderFormat(squirrel.Dollar).
+		RunWith(contextRunner(s.Provider))
+
+	var backward, hasCursor bool
+	if s.QueryBuilder.Cursor != "" {
+		c, err := decodeKeysetCursor(keyset, s.QueryBuilder.Cursor)
+		if err != nil {
+			return nil, p, err
+		}
+		hasCursor, backward = true, c.Backward
+		qry = qry.Where(keysetCondition(keyset, c.Values, backward))
+	}
+	qry = qry.OrderBy(keysetOrderBy(keyset, backward)...)
+
+	// Retrieve an extra row to determine if there are more results.
+	limit := s.QueryBuilder.Limit
+	if limit > 0 {
+		qry = qry.Limit(limit + 1)
+	}
+
+	// Add preprocessor
+	if s.PreprocessSelect != nil {
+		qry = s.PreprocessSelect(s.QueryBuilder.From, qry)
+	}
+
+	fn := func(val []R, err error) ([]R, KeysetPage, error) {
+		if s.ProcessSelectResult != nil {
+			s.ProcessSelectResult(val, err)
+		}
+		return val, p, err
+	}
+
+	rows, err := qry.QueryContext(ctx)
+	if err != nil {
+		return fn(nil, err)
+	}
+	it := &SelectIterator[R]{
+		Rows: rows,
+		Fn:   scanner,
+		Ctx:  ctx,
+	}
+	defer it.Close()
+
+	var xs []R
+	for {
+		obj, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return fn(nil, err)
+		}
+		xs = append(xs, obj)
+	}
+
+	hasMore := limit > 0 && uint64(len(xs)) > limit
+	if hasMore {
+		xs = xs[:limit]
+	}
+	if backward {
+		for i, j := 0, len(xs)-1; i < j; i, j = i+1, j-1 {
+			xs[i], xs[j] = xs[j], xs[i]
+		}
+	}
+	if len(xs) == 0 {
+		return fn(xs, nil)
+	}
+
+	// Going forward, there is a next page if there are more results, and a previous page if a cursor was provided.
+	// Going backward, it is the opposite.
+	if (!backward && hasMore) || backward {
+		if p.Next, err = encodeKeysetCursor(keyset, s.KeysetValues(xs[len(xs)-1]), false); err != nil {
+			return fn(nil, err)
+		}
+	}
+	if (backward && hasMore) || (!backward && hasCursor) {
+		if p.Previous, err = encodeKeysetCursor(keyset, s.KeysetValues(xs[0]), true); err != nil {
+			return fn(nil, err)
+		}
+	}
+	return fn(xs, nil)
+}
+
 // Iterate returns an iterator to retrieve objects with the columns provided.
 // It uses the conditions / paging / sort in the provided
 // Selector to restrict the output.
 func (s *Selector[T]) Iterate() (*SelectIterator[T], error) {
+	return s.IterateContext(context.Background())
+}
+
+// IterateContext is Iterate with a context. The rows are closed once the context is done.
+func (s *Selector[T]) IterateContext(ctx context.Context) (*SelectIterator[T], error) {
 	if len(s.GetCols) == 0 {
 		return nil, ErrSelectorMissingColumns
 	}
-	return Iterate[T](s, s.Scanner, s.processGetCols()...)
+	return IterateContext[T](ctx, s, s.Scanner, s.processGetCols()...)
 }
 
 // Iterate returns an iterator for retrieving multiple rows sequentially From the database.
 func Iterate[R any](s *Selector[R], scanner Scanner[R], cols ...string) (*SelectIterator[R], error) {
-	qry := s.QueryBuilder.Builder(cols...).
-		PlaceholderFormat(squirrel.Dollar).
-		RunWith(s.Provider.GetDb())
-	s.QueryBuilder.ApplyPaging(&qry)
-	s.QueryBuilder.ApplySort(&qry)
-
-	// Add preprocessor
-	if s.PreprocessSelect != nil {
-		qry = s.PreprocessSelect(s.QueryBuilder.From, qry)
-	}
+	return IterateContext[R](context.Background(), s, scanner, cols...)
+}
 
-	rows, err := qry.Query()
+// IterateContext is Iterate with a context. The rows are closed once the context is done.
+func IterateContext[R any](
+	ctx context.Context,
+	s *Selector[R],
+	scanner Scanner[R],
+	cols ...string,
+) (*SelectIterator[R], error) {
+	rows, err := s.SelectBuilder(cols...).
+		PlaceholderFormat(squirrel.Dollar).
+		RunWith(contextRunner(s.Provider)).
+		QueryContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -188,6 +420,7 @@ func Iterate[R any](s *Selector[R], scanner Scanner[R], cols ...string) (*Select
 	return &SelectIterator[R]{
 		Rows: rows,
 		Fn:   scanner,
+		Ctx:  ctx,
 	}, nil
 }
 
@@ -195,27 +428,37 @@ func Iterate[R any](s *Selector[R], scanner Scanner[R], cols ...string) (*Select
 // and apply conditions to it. It attempts to extract the column From TotalColumnName, but will default to COUNT(*)
 // if it is not provided.
 func (s *Selector[T]) Total() (uint64, error) {
+	return s.TotalContext(context.Background())
+}
+
+// TotalContext is Total with a context.
+func (s *Selector[T]) TotalContext(ctx context.Context) (uint64, error) {
 	col := s.TotalColumnName
 	if col == "" {
 		col = "COUNT(*)"
 	}
 
-	qry := s.QueryBuilder.Builder(col).
+	qry := s.builder(col).
 		PlaceholderFormat(squirrel.Dollar).
-		RunWith(s.Provider.GetDb())
+		RunWith(contextRunner(s.Provider))
 
 	// Add preprocessor
 	if s.PreprocessSelect != nil {
 		qry = s.PreprocessSelect(s.QueryBuilder.From, qry)
 	}
-	return SingleColumnScanner[uint64](qry)
+	return SingleColumnScanner[uint64](qry.QueryRowContext(ctx))
 }
 
 // Exists returns true if any result filtered by the query is present.
 func (s *Selector[T]) Exists() (bool, error) {
-	qry := s.QueryBuilder.Builder("*").
+	return s.ExistsContext(context.Background())
+}
+
+// ExistsContext is Exists with a context.
+func (s *Selector[T]) ExistsContext(ctx context.Context) (bool, error) {
+	qry := s.builder("*").
 		PlaceholderFormat(squirrel.Dollar).
-		RunWith(s.Provider.GetDb()).
+		RunWith(contextRunner(s.Provider)).
 		Prefix("SELECT EXISTS(").
 		Suffix(")")
 
@@ -223,7 +466,7 @@ func (s *Selector[T]) Exists() (bool, error) {
 	if s.PreprocessSelect != nil {
 		qry = s.PreprocessSelect(s.QueryBuilder.From, qry)
 	}
-	return SingleColumnScanner[bool](qry)
+	return SingleColumnScanner[bool](qry.QueryRowContext(ctx))
 }
 
 func SingleColumnScanner[T any](row squirrel.RowScanner) (T, error) {
@@ -237,12 +480,29 @@ func SingleColumnScanner[T any](row squirrel.RowScanner) (T, error) {
 type SelectIterator[R any] struct {
 	Rows *sql.Rows // Rows to iterate over.
 	Fn   Scanner[R]
+
+	// Ctx is the context of the query, if any. Once it is done, the rows are closed and Next returns its error.
+	Ctx context.Context
+}
+
+// Close closes the underlying rows.
+func (i *SelectIterator[R]) Close() error {
+	return i.Rows.Close()
 }
 
 // Next returns the next item in the list.
 func (i *SelectIterator[R]) Next() (R, error) {
+	var r R
+	if i.Ctx != nil {
+		if err := i.Ctx.Err(); err != nil {
+			i.Rows.Close()
+			return r, err
+		}
+	}
 	if !i.Rows.Next() {
-		var r R
+		if err := i.Rows.Err(); err != nil {
+			return r, err
+		}
 		return r, iterator.Done
 	}
 	return i.Fn.Scan(i.Rows)
@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// SoftDelete configures soft deletion. A row is considered deleted if Column is not NULL.
+//
+// For the Updater, Delete sets Column to Value instead of deleting the row. For the Selector, deleted rows are filtered
+// out unless IncludeDeleted is set.
+type SoftDelete struct {
+	// Column is the unqualified column, e.g., deleted_at.
+	Column string
+
+	// Value is the value that the column is set to on delete. Defaults to NOW().
+	Value interface{}
+}
+
+// NewSoftDelete returns a SoftDelete on the column, which is set to NOW() on delete.
+func NewSoftDelete(column string) *SoftDelete {
+	return &SoftDelete{Column: column}
+}
+
+func (d *SoftDelete) value() interface{} {
+	if d.Value == nil {
+		return squirrel.Expr("NOW()")
+	}
+	return d.Value
+}
+
+// Condition returns the condition which excludes deleted rows. The column is qualified with the alias of the table
+// (the last word of from, e.g., r for "release r"), if provided.
+func (d *SoftDelete) Condition(from string) squirrel.Sqlizer {
+	col := d.Column
+	if f := strings.Fields(from); len(f) > 0 {
+		col = f[len(f)-1] + "." + col
+	}
+	return squirrel.Expr(col + " IS NULL")
+}
@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/monstercat/golib/dao/daohelpers"
+)
+
+func TestUpdater_SoftDelete(t *testing.T) {
+	p := &ctxProvider{db: &ctxDb{}}
+	ctx := context.Background()
+
+	u := NewUpdater[string](NewStatementBuilder(), "release").
+		SetProvider(p).
+		SetIdColumn("id").
+		SetSoftDelete("deleted_at")
+	u.QueryBuilder.AddCondition(squirrel.Eq{"id": "1"})
+
+	assert.ErrorIs(t, u.DeleteContext(ctx), daohelpers.ErrNoDeletePerformed)
+	assert.ErrorIs(t, u.HardDeleteContext(ctx), daohelpers.ErrNoDeletePerformed)
+
+	u.SetVersion("version", 3)
+	u.Set("title", "hello")
+	assert.ErrorIs(t, u.UpdateContext(ctx), ErrVersionConflict)
+	assert.ErrorIs(t, u.DeleteContext(ctx), ErrVersionConflict)
+
+	assert.Equal(t, []string{
+		"UPDATE release SET deleted_at = NOW() WHERE (id = $1) AND release.deleted_at IS NULL",
+		"DELETE FROM release WHERE (id = $1)",
+		"UPDATE release SET title = $1, version = version + 1 WHERE (id = $2) AND release.version = $3",
+		"UPDATE release SET deleted_at = NOW(), version = version + 1 WHERE (id = $1) AND release.version = $2 " +
+			"AND release.deleted_at IS NULL",
+	}, p.db.queries)
+
+	// The data is not modified by the version.
+	assert.Equal(t, map[string]interface{}{"title": "hello"}, u.Data)
+}
+
+func TestSelector_SoftDelete(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	p := &ctxProvider{db: &ctxDb{}}
+	s := &Selector[string]{
+		QueryBuilder: NewSelectBuilder(NewStatementBuilder()).SetFrom("release r"),
+		Provider:     p,
+		GetCols:      []string{"title"},
+		Scanner:      ScannerFunc[string](SingleColumnScanner[string]),
+	}
+	s.SetSoftDelete("deleted_at")
+
+	_, err := s.SelectContext(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	s.SetIncludeDeleted(true)
+	_, err = s.SelectContext(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	assert.Equal(t, []string{
+		" SELECT title FROM release r WHERE r.deleted_at IS NULL",
+		" SELECT title FROM release r",
+	}, p.db.queries)
+}
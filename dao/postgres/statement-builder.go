@@ -8,9 +8,14 @@ import (
 
 // StatementBuilder helps with building select, update and delete queries.
 type StatementBuilder struct {
-	// Tables to add to the top. Use the map to make sure that if it has been added or not.
+	// Tables to add to the top. Use the map to make sure that if it has been added or not. They are output after CTEs,
+	// sorted by name.
 	WithPrefix With
 
+	// CTEs are the tables to add to the top, in the order that they are added through AddCTE. CTEs are unique by name
+	// across both CTEs and WithPrefix.
+	CTEs *OrderedWith
+
 	// Tables to be joined. The reason that this is part of the statement builder is because sometimes the conditions
 	// require that the table be included.
 	//
@@ -29,6 +34,12 @@ type StatementBuilder struct {
 
 	// Offset is an Offset for the query.
 	Offset uint64
+
+	// Keyset contains the columns used for keyset pagination. See Selector.SelectKeyset.
+	Keyset []KeysetColumn
+
+	// Cursor is the opaque cursor for keyset pagination. It is only used by Selector.SelectKeyset, which ignores Offset.
+	Cursor string
 }
 
 // NewStatementBuilder generates a query builder and initiates all required internal variables.
@@ -64,18 +75,40 @@ func (d *StatementBuilder) GetOffset() uint64 {
 	return d.Offset
 }
 
-// AddPrefix adds a prefix to the StatementBuilder.
+// AddPrefix adds a prefix to the StatementBuilder. Prefixes are output after CTEs, sorted by name; use AddCTE if the
+// order matters. If a prefix or CTE of the same name has already been added, it is ignored.
 func (d *StatementBuilder) AddPrefix(name string, prefix squirrel.Sqlizer) *StatementBuilder {
 	if d.WithPrefix == nil {
 		d.WithPrefix = make(map[string]squirrel.Sqlizer)
 	}
-	if _, ok := d.WithPrefix[name]; ok {
+	if _, ok := d.WithPrefix[name]; ok || d.CTEs.Has(name) {
 		return d
 	}
 	d.WithPrefix[name] = prefix
 	return d
 }
 
+// AddCTE adds a CTE to the StatementBuilder. CTEs are output in order, before WithPrefix, which allows them to reference
+// each other. This also allows recursive CTEs, column lists and materialization hints. If a CTE or prefix of the same
+// name has already been added, it is ignored.
+func (d *StatementBuilder) AddCTE(cte CTE) *StatementBuilder {
+	if _, ok := d.WithPrefix[cte.Name]; ok {
+		return d
+	}
+	d.CTEs = d.CTEs.AddCTE(cte)
+	return d
+}
+
+// With returns the WITH statement of the StatementBuilder, containing CTEs followed by WithPrefix. The returned
+// statement can be added to without modifying the StatementBuilder.
+func (d *StatementBuilder) With() *OrderedWith {
+	with := d.CTEs.Clone()
+	for _, cte := range d.WithPrefix.ordered().CTEs {
+		with.AddCTE(cte)
+	}
+	return with
+}
+
 // AddJoin adds an inner join to the query builder.
 func (d *StatementBuilder) AddJoin(name string, join string, xs ...ConditionOption) *StatementBuilder {
 	d.addJoin(name, join, JoinTypeInner, xs...)
@@ -135,6 +168,23 @@ func (d *StatementBuilder) SetOffset(offset uint64) *StatementBuilder {
 	return d
 }
 
+// SetKeyset sets the columns used for keyset pagination.
+func (d *StatementBuilder) SetKeyset(cols ...KeysetColumn) *StatementBuilder {
+	d.Keyset = cols
+	return d
+}
+
+// SetCursor sets the cursor for keyset pagination.
+func (d *StatementBuilder) SetCursor(cursor string) *StatementBuilder {
+	d.Cursor = cursor
+	return d
+}
+
+// GetCursor returns the cursor on the StatementBuilder
+func (d *StatementBuilder) GetCursor() string {
+	return d.Cursor
+}
+
 // AddSort adds an orderBy to the OrderBys field for the query.
 func (d *StatementBuilder) AddSort(sort []string, xs ...ConditionOption) *StatementBuilder {
 	for _, s := range sort {
@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+
+	"github.com/Masterminds/squirrel"
+
+	dbutil "github.com/monstercat/golib/db"
+)
+
+var (
+	ErrStructScannerType = errors.New("struct scanner requires a struct or a pointer to a struct")
+)
+
+// StructScanner is a Scanner which derives both the columns and the scanning logic from the struct tags of T, using
+// the same rules as dbutil.GetColumnsForSet (db, select and select-sets tags). Embedded structs are included, and
+// struct fields tagged with select-join are scanned from the columns of the joined table, e.g.,
+//
+//	type Release struct {
+//	    Id     string `db:"id"`
+//	    Title  string `db:"title"`
+//	    Label  Label  `select-join:"l"`
+//	}
+//
+// will select t.id, t.title, l.id, l.title (given a prefix of t and Label containing id and title).
+//
+// T can either be a struct or a pointer to a struct. Scanners are cached per type, set and prefix, so calling
+// NewStructScanner repeatedly is cheap.
+type StructScanner[T any] struct {
+	fields []dbutil.ColumnField
+	cols   []string
+	ptr    bool
+	typ    reflect.Type
+}
+
+type structScannerKey struct {
+	typ    reflect.Type
+	set    string
+	prefix string
+}
+
+var structScanners sync.Map
+
+// NewStructScanner returns the scanner for T. Set is the select set (see dbutil.SelectSetTagName) which can be empty.
+// The prefix is applied to the columns of T (but not to joined structs) and can be TablePlaceholder.
+func NewStructScanner[T any](set, prefix string) (*StructScanner[T], error) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	key := structScannerKey{typ: typ, set: set, prefix: prefix}
+	if s, ok := structScanners.Load(key); ok {
+		return s.(*StructScanner[T]), nil
+	}
+
+	s := &StructScanner[T]{typ: typ}
+	if typ.Kind() == reflect.Ptr {
+		s.ptr = true
+		s.typ = typ.Elem()
+	}
+	if s.typ.Kind() != reflect.Struct {
+		return nil, ErrStructScannerType
+	}
+	s.fields = dbutil.GetColumnFields(set, reflect.New(s.typ).Interface(), prefix)
+	s.cols = make([]string, 0, len(s.fields))
+	for _, f := range s.fields {
+		s.cols = append(s.cols, f.Column)
+	}
+
+	actual, _ := structScanners.LoadOrStore(key, s)
+	return actual.(*StructScanner[T]), nil
+}
+
+// Columns returns the columns to select. They are in the order expected by Scan.
+func (s *StructScanner[T]) Columns() []string {
+	cols := make([]string, len(s.cols))
+	copy(cols, s.cols)
+	return cols
+}
+
+// Scan scans the row into a new T.
+func (s *StructScanner[T]) Scan(row squirrel.RowScanner) (T, error) {
+	var t T
+	v := reflect.New(s.typ)
+	dest := make([]interface{}, 0, len(s.fields))
+	for _, f := range s.fields {
+		dest = append(dest, v.Elem().FieldByIndex(f.Index).Addr().Interface())
+	}
+	if err := row.Scan(dest...); err != nil {
+		return t, err
+	}
+	if s.ptr {
+		return v.Interface().(T), nil
+	}
+	return v.Elem().Interface().(T), nil
+}
+
+// Apply sets the Scanner and GetCols of the selector.
+func (s *StructScanner[T]) Apply(sel *Selector[T]) *Selector[T] {
+	sel.Scanner = s
+	sel.GetCols = s.Columns()
+	return sel
+}
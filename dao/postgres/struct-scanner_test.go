@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"reflect"
+	"testing"
+)
+
+// valuesRow is a squirrel.RowScanner which scans the values in order.
+type valuesRow []interface{}
+
+func (r valuesRow) Scan(dest ...interface{}) error {
+	for i, d := range dest {
+		reflect.ValueOf(d).Elem().Set(reflect.ValueOf(r[i]))
+	}
+	return nil
+}
+
+type scannerLabel struct {
+	Id    string `db:"id"`
+	Title string `db:"title" select-sets:"full"`
+}
+
+type scannerBase struct {
+	Id string `db:"id"`
+}
+
+type scannerRelease struct {
+	scannerBase
+	Title   string       `db:"title"`
+	Upc     string       `db:"upc" select:"coalesce"`
+	Notes   string       `db:"notes" select-sets:"full"`
+	Ignored string       `db:"ignored" select:"-"`
+	Label   scannerLabel `select-join:"l"`
+}
+
+func TestStructScanner(t *testing.T) {
+	s, err := NewStructScanner[scannerRelease]("", "t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"t.id", "t.title", "COALESCE(t.upc, '') as upc", "t.notes", "l.id", "l.title"}
+	if cols := s.Columns(); !reflect.DeepEqual(cols, expected) {
+		t.Errorf("Expected %v, got %v", expected, cols)
+	}
+
+	rel, err := s.Scan(valuesRow{"1", "Release", "UPC", "Notes", "2", "Label"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedRel := scannerRelease{
+		scannerBase: scannerBase{Id: "1"},
+		Title:       "Release",
+		Upc:         "UPC",
+		Notes:       "Notes",
+		Label:       scannerLabel{Id: "2", Title: "Label"},
+	}
+	if rel != expectedRel {
+		t.Errorf("Expected %#v, got %#v", expectedRel, rel)
+	}
+
+	// Cached
+	s2, _ := NewStructScanner[scannerRelease]("", "t")
+	if s2 != s {
+		t.Error("Expecting scanner to be cached")
+	}
+
+	// Sets and pointers
+	ps, err := NewStructScanner[*scannerRelease]("full", TablePlaceholder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{"[[table]].notes", "l.title"}
+	if cols := ps.Columns(); !reflect.DeepEqual(cols, expected) {
+		t.Errorf("Expected %v, got %v", expected, cols)
+	}
+	prel, err := ps.Scan(valuesRow{"Notes", "Label"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prel.Notes != "Notes" || prel.Label.Title != "Label" {
+		t.Errorf("Unexpected result %#v", prel)
+	}
+
+	if _, err := NewStructScanner[string]("", ""); err != ErrStructScannerType {
+		t.Errorf("Expecting ErrStructScannerType, got %v", err)
+	}
+}
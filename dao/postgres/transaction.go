@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/monstercat/golib/dao/transaction"
+	dbutil "github.com/monstercat/golib/db"
+)
+
+// TransactionContextKey is the key of the TransactionContext in transaction.Transaction.Contexts.
+const TransactionContextKey = "postgres"
+
+var (
+	ErrTransactionDone = errors.New("transaction has already been committed or rolled back")
+)
+
+// TransactionContext is a transaction.Context for postgres. The sqlx.Tx is only started when the connection is first
+// requested, so that transactions which do not touch postgres do not hold a connection.
+//
+// It implements DBProvider, so it can be passed directly as the Provider of a Selector or Updater, making them run
+// inside the transaction.
+//
+// It implements transaction.Nester. Nested contexts share the sqlx.Tx of the original, using a savepoint which is
+// created on first use.
+type TransactionContext struct {
+	// DB used to begin the transaction.
+	DB dbutil.TxBeginner
+
+	// Ctx is the context used to begin the transaction. Defaults to context.Background.
+	Ctx context.Context
+
+	// Options used to begin the transaction.
+	Options *sql.TxOptions
+
+	mu   sync.Mutex
+	tx   *sqlx.Tx
+	err  error
+	done bool
+
+	// For nested contexts, the enclosing context and the name of the savepoint.
+	parent    *TransactionContext
+	savepoint string
+}
+
+// NewTransactionContext creates a context which begins a transaction on the provided DB.
+func NewTransactionContext(db dbutil.TxBeginner) *TransactionContext {
+	return &TransactionContext{
+		DB: db,
+	}
+}
+
+// UseTransaction retrieves the TransactionContext from the transaction, creating it if it does not exist.
+func UseTransaction(tx *transaction.Transaction, db dbutil.TxBeginner) *TransactionContext {
+	return transaction.GetContext[*TransactionContext](tx, TransactionContextKey, func() *TransactionContext {
+		return NewTransactionContext(db)
+	})
+}
+
+// Nest returns a context which uses a savepoint within this transaction.
+func (c *TransactionContext) Nest() transaction.Context {
+	return &TransactionContext{
+		DB:        c.DB,
+		Ctx:       c.Ctx,
+		Options:   c.Options,
+		parent:    c,
+		savepoint: dbutil.NewSavepointName(),
+	}
+}
+
+func (c *TransactionContext) context() context.Context {
+	if c.Ctx == nil {
+		return context.Background()
+	}
+	return c.Ctx
+}
+
+// Tx returns the transaction, beginning it if it has not yet started. Once the transaction has been committed or
+// rolled back, ErrTransactionDone is returned.
+func (c *TransactionContext) Tx() (*sqlx.Tx, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.done {
+		return nil, ErrTransactionDone
+	}
+	if c.tx != nil || c.err != nil {
+		return c.tx, c.err
+	}
+
+	if c.parent == nil {
+		c.tx, c.err = c.DB.BeginTxx(c.context(), c.Options)
+		return c.tx, c.err
+	}
+
+	tx, err := c.parent.Tx()
+	if err == nil {
+		err = dbutil.CreateSavepoint(c.context(), tx, c.savepoint)
+	}
+	if err != nil {
+		c.err = err
+		return nil, err
+	}
+	c.tx = tx
+	return c.tx, nil
+}
+
+// GetDb returns the transaction. If the transaction could not be started, nil is returned; Tx and DbError return the
+// reason. Selector and Updater check DbError, so their queries fail with the reason.
+func (c *TransactionContext) GetDb() sqlx.Ext {
+	tx, err := c.Tx()
+	if err != nil {
+		return nil
+	}
+	return tx
+}
+
+// GetDbContext returns the transaction. If the transaction could not be started, nil is returned; Tx and DbError
+// return the reason.
+func (c *TransactionContext) GetDbContext() sqlx.ExtContext {
+	tx, err := c.Tx()
+	if err != nil {
+		return nil
+	}
+	return tx
+}
+
+// DbError returns the error encountered while beginning the transaction, if any. It implements DBErrorProvider.
+func (c *TransactionContext) DbError() error {
+	_, err := c.Tx()
+	return err
+}
+
+// finish returns the transaction if one was started, marking the context as done.
+func (c *TransactionContext) finish() *sqlx.Tx {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.done {
+		return nil
+	}
+	c.done = true
+	return c.tx
+}
+
+// TryRollback rolls back the transaction if it was started. A nested context rolls back to its savepoint.
+func (c *TransactionContext) TryRollback() error {
+	tx := c.finish()
+	if tx == nil {
+		return nil
+	}
+	if c.parent != nil {
+		return dbutil.RollbackToSavepoint(c.context(), tx, c.savepoint)
+	}
+	return tx.Rollback()
+}
+
+// TryCommit commits the transaction if it was started. A nested context releases its savepoint.
+func (c *TransactionContext) TryCommit() error {
+	tx := c.finish()
+	if tx == nil {
+		return nil
+	}
+	if c.parent != nil {
+		return dbutil.ReleaseSavepoint(c.context(), tx, c.savepoint)
+	}
+	return tx.Commit()
+}
+
+// Rollback is TryRollback, ignoring the error.
+func (c *TransactionContext) Rollback() {
+	_ = c.TryRollback()
+}
+
+// Commit is TryCommit, ignoring the error.
+func (c *TransactionContext) Commit() {
+	_ = c.TryCommit()
+}
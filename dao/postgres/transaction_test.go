@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/monstercat/golib/dao/transaction"
+)
+
+type beginner struct {
+	calls int
+	err   error
+}
+
+func (b *beginner) BeginTxx(context.Context, *sql.TxOptions) (*sqlx.Tx, error) {
+	b.calls++
+	return nil, b.err
+}
+
+func TestTransactionContext(t *testing.T) {
+	// A transaction which does not use postgres never begins.
+	db := &beginner{}
+	require.NoError(t, transaction.Tx(func(tx *transaction.Transaction) error {
+		UseTransaction(tx, db)
+		assert.Same(t, UseTransaction(tx, db), tx.Contexts[TransactionContextKey])
+		return nil
+	}))
+	assert.Equal(t, 0, db.calls)
+
+	// Queries fail with the error from beginning the transaction.
+	db = &beginner{err: errors.New("too many connections")}
+	err := transaction.Tx(func(tx *transaction.Transaction) error {
+		s := &Selector[string]{
+			QueryBuilder: NewSelectBuilder(NewStatementBuilder()).SetFrom("release"),
+			Provider:     UseTransaction(tx, db),
+			GetCols:      []string{"title"},
+			Scanner:      ScannerFunc[string](SingleColumnScanner[string]),
+		}
+		if _, err := s.Get(); err != nil {
+			return err
+		}
+		u := NewUpdater[string](NewStatementBuilder(), "release").SetProvider(UseTransaction(tx, db))
+		u.Set("title", "hello")
+		u.QueryBuilder.AddCondition(squirrel.Eq{"id": "1"})
+		return u.Update()
+	})
+	assert.ErrorIs(t, err, db.err)
+	assert.Equal(t, 1, db.calls)
+
+	// Once finished, the transaction cannot be used.
+	c := NewTransactionContext(&beginner{})
+	require.NoError(t, c.TryCommit())
+	_, err = c.Tx()
+	assert.ErrorIs(t, err, ErrTransactionDone)
+}
+
+func TestTransactionContext_GetDbError(t *testing.T) {
+	db := &beginner{err: errors.New("too many connections")}
+	c := NewTransactionContext(db)
+
+	// The connection is unavailable; the reason is returned by Tx and DbError.
+	assert.Nil(t, c.GetDb())
+	assert.Nil(t, c.GetDbContext())
+	_, err := c.Tx()
+	assert.ErrorIs(t, err, db.err)
+	assert.ErrorIs(t, c.DbError(), db.err)
+
+	// Updaters using the context fail with the reason instead of using the connection.
+	u := NewUpdater[string](NewStatementBuilder(), "release").SetProvider(c)
+	u.Set("title", "hello")
+	u.QueryBuilder.AddCondition(squirrel.Eq{"id": "1"})
+	assert.ErrorIs(t, u.Update(), db.err)
+
+	assert.Equal(t, 1, db.calls)
+}
@@ -50,12 +50,10 @@ func (d *UpdateBuilder) generateAdditionalWith() squirrel.SelectBuilder {
 		Builder(d.IdColumnName)
 }
 
-func (d *UpdateBuilder) preparePrefixExprForCondition() (With, squirrel.Sqlizer) {
-	with := make(With)
-	for k, v := range d.WithPrefix {
-		with[k] = v
-	}
-	with[d.table+"_condition"] = d.generateAdditionalWith()
+func (d *UpdateBuilder) preparePrefixExprForCondition() (*OrderedWith, squirrel.Sqlizer) {
+	// The condition is added last, so that it can reference the other CTEs.
+	with := d.With()
+	with.Add(d.table+"_condition", d.generateAdditionalWith())
 	return with, squirrel.Expr(fmt.Sprintf("%s IN (SELECT %[1]s FROM %[2]s_condition)", d.IdColumnName, d.table))
 }
 
@@ -82,6 +80,11 @@ func (d *UpdateBuilder) InsertBuilder(setMap map[string]interface{}) squirrel.In
 	return squirrel.Insert(d.table).SetMap(setMap)
 }
 
+// UpsertBuilder returns an insert builder with the provided set information, followed by the ON CONFLICT clause.
+func (d *UpdateBuilder) UpsertBuilder(setMap map[string]interface{}, conflict *OnConflict) squirrel.InsertBuilder {
+	return d.InsertBuilder(setMap).SuffixExpr(conflict.Sqlizer(setMap))
+}
+
 func (d *UpdateBuilder) DeleteBuilder() squirrel.DeleteBuilder {
 	qry := squirrel.Delete(d.table)
 	if d.HasJoins() {
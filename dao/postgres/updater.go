@@ -1,7 +1,10 @@
 package postgres
 
 import (
+	"context"
+	"database/sql"
 	"errors"
+	"strings"
 
 	"github.com/Masterminds/squirrel"
 
@@ -10,6 +13,11 @@ import (
 
 var (
 	ErrMissingProvider = errors.New("missing db provider")
+
+	// ErrVersionConflict is returned by the Updater instead of daohelpers.ErrNoUpdatePerformed (or
+	// daohelpers.ErrNoDeletePerformed) when optimistic locking is enabled and no rows matched. Either the row was
+	// modified since it was retrieved, or it does not exist.
+	ErrVersionConflict = errors.New("version conflict")
 )
 
 // Updater provides some default insert/update/delete functionality. The parameter T is the type for the ID which is
@@ -33,6 +41,20 @@ type Updater[T any] struct {
 	// Set of data to update with.
 	Data map[string]interface{}
 
+	// OnConflict is the ON CONFLICT clause used by Upsert.
+	OnConflict *OnConflict
+
+	// SoftDelete, if set, turns Delete into an update of its column. Rows which have already been deleted are not
+	// updated. Use HardDelete to delete the rows.
+	SoftDelete *SoftDelete
+
+	// VersionColumn enables optimistic locking. Update and Delete only affect rows where the column equals Version,
+	// and increment the column. If no rows are affected, ErrVersionConflict is returned.
+	VersionColumn string
+
+	// Version is the expected value of VersionColumn.
+	Version interface{}
+
 	// Any error through SET logic.
 	err error
 }
@@ -62,11 +84,35 @@ func (u *Updater[T]) SetIdColumn(col string) *Updater[T] {
 	return u
 }
 
+// SetOnConflict sets the ON CONFLICT clause used by Upsert.
+func (u *Updater[T]) SetOnConflict(conflict OnConflict) *Updater[T] {
+	u.OnConflict = &conflict
+	return u
+}
+
+// SetSoftDelete enables soft deletion using the column. See SoftDelete.
+func (u *Updater[T]) SetSoftDelete(column string) *Updater[T] {
+	u.SoftDelete = NewSoftDelete(column)
+	return u
+}
+
+// SetVersion enables optimistic locking, expecting the column to be equal to the version.
+func (u *Updater[T]) SetVersion(column string, version interface{}) *Updater[T] {
+	u.VersionColumn = column
+	u.Version = version
+	return u
+}
+
 func (u *Updater[T]) Set(name string, value interface{}) {
 	u.Data[name] = value
 }
 
 func (u *Updater[T]) Update() error {
+	return u.UpdateContext(context.Background())
+}
+
+// UpdateContext is Update with a context.
+func (u *Updater[T]) UpdateContext(ctx context.Context) error {
 	if u.Provider == nil {
 		return ErrMissingProvider
 	}
@@ -77,14 +123,10 @@ func (u *Updater[T]) Update() error {
 	if u.err != nil {
 		return u.err
 	}
-	qry := u.QueryBuilder.UpdateBuilder(u.Data)
-	if u.PreprocessUpdate != nil {
-		qry = u.PreprocessUpdate(u.QueryBuilder.table, qry)
-	}
-	res, err := qry.
+	res, err := u.updateBuilder(u.Data).
 		PlaceholderFormat(squirrel.Dollar).
-		RunWith(u.Provider.GetDb()).
-		Exec()
+		RunWith(contextRunner(u.Provider)).
+		ExecContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -96,43 +138,120 @@ func (u *Updater[T]) Update() error {
 		return err
 	}
 	if rows == 0 {
-		return daohelpers.ErrNoUpdatePerformed
+		return u.noRowsError(daohelpers.ErrNoUpdatePerformed)
 	}
 	return nil
 }
 
 func (u *Updater[T]) Insert() (T, error) {
+	return u.InsertContext(context.Background())
+}
+
+// InsertContext is Insert with a context.
+func (u *Updater[T]) InsertContext(ctx context.Context) (T, error) {
 	var id T
 	err := u.QueryBuilder.InsertBuilder(u.Data).
 		PlaceholderFormat(squirrel.Dollar).
-		Suffix("RETURNING " + u.QueryBuilder.IdColumnName).
-		RunWith(u.Provider.GetDb()).
-		Scan(&id)
+		Suffix("RETURNING "+u.QueryBuilder.IdColumnName).
+		RunWith(contextRunner(u.Provider)).
+		ScanContext(ctx, &id)
 	return id, err
 }
 
 func (u *Updater[T]) InsertNoId() error {
+	return u.InsertNoIdContext(context.Background())
+}
+
+// InsertNoIdContext is InsertNoId with a context.
+func (u *Updater[T]) InsertNoIdContext(ctx context.Context) error {
 	_, err := u.QueryBuilder.InsertBuilder(u.Data).
 		PlaceholderFormat(squirrel.Dollar).
-		RunWith(u.Provider.GetDb()).
-		Exec()
+		RunWith(contextRunner(u.Provider)).
+		ExecContext(ctx)
 	return err
 }
 
+// Upsert inserts the data, resolving conflicts through OnConflict. It returns the ID of the inserted or updated row and
+// whether the row was inserted or updated. If the row was skipped, the ID is empty and UpsertSkipped is returned.
+func (u *Updater[T]) Upsert() (T, UpsertResult, error) {
+	return u.UpsertContext(context.Background())
+}
+
+// UpsertContext is Upsert with a context.
+func (u *Updater[T]) UpsertContext(ctx context.Context) (T, UpsertResult, error) {
+	var id T
+	if u.Provider == nil {
+		return id, UpsertSkipped, ErrMissingProvider
+	}
+	if u.OnConflict == nil {
+		return id, UpsertSkipped, ErrUpsertMissingConflict
+	}
+	if u.err != nil {
+		return id, UpsertSkipped, u.err
+	}
+
+	qry := u.QueryBuilder.UpsertBuilder(u.Data, u.OnConflict)
+	if u.PreprocessInsert != nil {
+		qry = u.PreprocessInsert(u.QueryBuilder.table, qry)
+	}
+
+	// xmax is only set if the row was updated.
+	var inserted bool
+	err := qry.
+		PlaceholderFormat(squirrel.Dollar).
+		Suffix("RETURNING "+u.QueryBuilder.IdColumnName+", (xmax = 0)").
+		RunWith(contextRunner(u.Provider)).
+		ScanContext(ctx, &id, &inserted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return id, UpsertSkipped, nil
+	}
+	if err != nil {
+		return id, UpsertSkipped, err
+	}
+	if inserted {
+		return id, UpsertInserted, nil
+	}
+	return id, UpsertUpdated, nil
+}
+
+// Delete deletes the rows. If SoftDelete is set, the rows are soft deleted instead.
 func (u *Updater[T]) Delete() error {
+	return u.DeleteContext(context.Background())
+}
+
+// DeleteContext is Delete with a context.
+func (u *Updater[T]) DeleteContext(ctx context.Context) error {
+	return u.deleteContext(ctx, u.SoftDelete != nil)
+}
+
+// HardDelete deletes the rows, even if SoftDelete is set.
+func (u *Updater[T]) HardDelete() error {
+	return u.HardDeleteContext(context.Background())
+}
+
+// HardDeleteContext is HardDelete with a context.
+func (u *Updater[T]) HardDeleteContext(ctx context.Context) error {
+	return u.deleteContext(ctx, false)
+}
+
+func (u *Updater[T]) deleteContext(ctx context.Context, soft bool) error {
 	if !u.QueryBuilder.HasConditions() {
 		return daohelpers.ErrNoConditions
 	}
 
-	qry := u.QueryBuilder.DeleteBuilder()
-	if u.PreprocessDelete != nil {
-		qry = u.PreprocessDelete(u.QueryBuilder.table, qry)
+	var res sql.Result
+	var err error
+	if soft {
+		res, err = u.softDeleteBuilder().
+			PlaceholderFormat(squirrel.Dollar).
+			RunWith(contextRunner(u.Provider)).
+			ExecContext(ctx)
+	} else {
+		res, err = u.deleteBuilder().
+			PlaceholderFormat(squirrel.Dollar).
+			RunWith(contextRunner(u.Provider)).
+			ExecContext(ctx)
 	}
-
-	res, err := qry.
-		PlaceholderFormat(squirrel.Dollar).
-		RunWith(u.Provider.GetDb()).
-		Exec()
 	if err != nil {
 		return err
 	}
@@ -144,7 +263,170 @@ func (u *Updater[T]) Delete() error {
 		return err
 	}
 	if rows == 0 {
-		return daohelpers.ErrNoDeletePerformed
+		return u.noRowsError(daohelpers.ErrNoDeletePerformed)
 	}
 	return nil
 }
+
+// updateBuilder returns the update query for the data, including the optimistic locking condition and increment.
+func (u *Updater[T]) updateBuilder(data map[string]interface{}) squirrel.UpdateBuilder {
+	if u.VersionColumn != "" {
+		m := make(map[string]interface{}, len(data)+1)
+		for k, v := range data {
+			m[k] = v
+		}
+		m[u.VersionColumn] = squirrel.Expr(u.VersionColumn + " + 1")
+		data = m
+	}
+	qry := u.QueryBuilder.UpdateBuilder(data)
+	if u.VersionColumn != "" {
+		qry = qry.Where(u.versionCondition())
+	}
+	if u.PreprocessUpdate != nil {
+		qry = u.PreprocessUpdate(u.QueryBuilder.table, qry)
+	}
+	return qry
+}
+
+// softDeleteBuilder returns the update query which soft deletes the rows. As it is an update, PreprocessUpdate is used.
+func (u *Updater[T]) softDeleteBuilder() squirrel.UpdateBuilder {
+	return u.updateBuilder(map[string]interface{}{
+		u.SoftDelete.Column: u.SoftDelete.value(),
+	}).Where(u.SoftDelete.Condition(u.QueryBuilder.table))
+}
+
+// deleteBuilder returns the delete query, including the optimistic locking condition.
+func (u *Updater[T]) deleteBuilder() squirrel.DeleteBuilder {
+	qry := u.QueryBuilder.DeleteBuilder()
+	if u.VersionColumn != "" {
+		qry = qry.Where(u.versionCondition())
+	}
+	if u.PreprocessDelete != nil {
+		qry = u.PreprocessDelete(u.QueryBuilder.table, qry)
+	}
+	return qry
+}
+
+// versionCondition returns the optimistic locking condition. The column is qualified with the table, as the conditions
+// may reference other tables.
+func (u *Updater[T]) versionCondition() squirrel.Sqlizer {
+	return squirrel.Eq{u.QueryBuilder.table + "." + u.VersionColumn: u.Version}
+}
+
+// noRowsError returns the error for when no rows were affected. With optimistic locking, it is ErrVersionConflict.
+func (u *Updater[T]) noRowsError(err error) error {
+	if u.VersionColumn != "" {
+		return ErrVersionConflict
+	}
+	return err
+}
+
+// returningSuffix returns the RETURNING clause for the columns. TablePlaceholder is replaced with the table.
+func (u *Updater[T]) returningSuffix(cols []string) string {
+	c := make([]string, 0, len(cols))
+	for _, col := range cols {
+		c = append(c, strings.Replace(col, TablePlaceholder, u.QueryBuilder.table, -1))
+	}
+	return "RETURNING " + strings.Join(c, ", ")
+}
+
+// UpdateReturning performs the update and returns the updated rows, scanned using the provided columns. If nothing
+// was updated, daohelpers.ErrNoUpdatePerformed is returned.
+func UpdateReturning[T, R any](u *Updater[T], scanner Scanner[R], cols ...string) ([]R, error) {
+	return UpdateReturningContext[T, R](context.Background(), u, scanner, cols...)
+}
+
+// UpdateReturningContext is UpdateReturning with a context.
+func UpdateReturningContext[T, R any](
+	ctx context.Context,
+	u *Updater[T],
+	scanner Scanner[R],
+	cols ...string,
+) ([]R, error) {
+	if u.Provider == nil {
+		return nil, ErrMissingProvider
+	}
+	if len(cols) == 0 {
+		return nil, ErrSelectorMissingColumns
+	}
+	if !u.QueryBuilder.HasConditions() {
+		return nil, daohelpers.ErrNoConditions
+	}
+	if u.err != nil {
+		return nil, u.err
+	}
+	rows, err := u.updateBuilder(u.Data).
+		PlaceholderFormat(squirrel.Dollar).
+		Suffix(u.returningSuffix(cols)).
+		RunWith(contextRunner(u.Provider)).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	xs, err := scanReturning(ctx, rows, scanner)
+	if err == nil && len(xs) == 0 {
+		err = u.noRowsError(daohelpers.ErrNoUpdatePerformed)
+	}
+	return xs, err
+}
+
+// DeleteReturning performs the delete and returns the deleted rows, scanned using the provided columns. If nothing
+// was deleted, daohelpers.ErrNoDeletePerformed is returned. If SoftDelete is set, the rows are soft deleted instead.
+func DeleteReturning[T, R any](u *Updater[T], scanner Scanner[R], cols ...string) ([]R, error) {
+	return DeleteReturningContext[T, R](context.Background(), u, scanner, cols...)
+}
+
+// DeleteReturningContext is DeleteReturning with a context.
+func DeleteReturningContext[T, R any](
+	ctx context.Context,
+	u *Updater[T],
+	scanner Scanner[R],
+	cols ...string,
+) ([]R, error) {
+	if u.Provider == nil {
+		return nil, ErrMissingProvider
+	}
+	if len(cols) == 0 {
+		return nil, ErrSelectorMissingColumns
+	}
+	if !u.QueryBuilder.HasConditions() {
+		return nil, daohelpers.ErrNoConditions
+	}
+	var rows *sql.Rows
+	var err error
+	if u.SoftDelete != nil {
+		rows, err = u.softDeleteBuilder().
+			PlaceholderFormat(squirrel.Dollar).
+			Suffix(u.returningSuffix(cols)).
+			RunWith(contextRunner(u.Provider)).
+			QueryContext(ctx)
+	} else {
+		rows, err = u.deleteBuilder().
+			PlaceholderFormat(squirrel.Dollar).
+			Suffix(u.returningSuffix(cols)).
+			RunWith(contextRunner(u.Provider)).
+			QueryContext(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	xs, err := scanReturning(ctx, rows, scanner)
+	if err == nil && len(xs) == 0 {
+		err = u.noRowsError(daohelpers.ErrNoDeletePerformed)
+	}
+	return xs, err
+}
+
+// scanReturning scans all rows returned through RETURNING.
+func scanReturning[R any](ctx context.Context, rows *sql.Rows, scanner Scanner[R]) ([]R, error) {
+	var xs []R
+	err := appendAll(&SelectIterator[R]{
+		Rows: rows,
+		Fn:   scanner,
+		Ctx:  ctx,
+	}, &xs)
+	if err != nil {
+		return nil, err
+	}
+	return xs, nil
+}
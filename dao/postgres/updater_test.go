@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateReturning(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	p := &ctxProvider{db: &ctxDb{}}
+	scanner := ScannerFunc[string](SingleColumnScanner[string])
+
+	u := NewUpdater[string](NewStatementBuilder(), "release").SetProvider(p).SetIdColumn("id")
+	u.Set("title", "hello")
+	u.QueryBuilder.AddCondition(squirrel.Eq{"id": "1"})
+
+	_, err := UpdateReturningContext[string, string](ctx, u, scanner, TablePlaceholder+".title")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = DeleteReturningContext[string, string](ctx, u, scanner, "title")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// With joins, the conditions are moved into the WITH statement.
+	u.QueryBuilder.AddJoin("artist", "artist ON artist.release_id = release.id")
+	_, err = UpdateReturningContext[string, string](ctx, u, scanner, "title")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = UpdateReturningContext[string, string](ctx, u, scanner)
+	assert.ErrorIs(t, err, ErrSelectorMissingColumns)
+
+	assert.Equal(t, []string{
+		"UPDATE release SET title = $1 WHERE (id = $2) RETURNING release.title",
+		"DELETE FROM release WHERE (id = $1) RETURNING title",
+		"WITH release_condition AS ( SELECT id FROM release JOIN artist ON artist.release_id = release.id WHERE (id = $1)) " +
+			"UPDATE release SET title = $2 WHERE id IN (SELECT id FROM release_condition) RETURNING title",
+	}, p.db.queries)
+}
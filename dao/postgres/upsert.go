@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+)
+
+var (
+	ErrUpsertMissingConflict = errors.New("missing conflict target")
+)
+
+// UpsertResult is the outcome of an upsert.
+type UpsertResult int
+
+const (
+	// UpsertSkipped means that the row conflicted and nothing was done, either through DoNothing or because the Where
+	// condition on the update was not satisfied.
+	UpsertSkipped UpsertResult = iota
+	UpsertInserted
+	UpsertUpdated
+)
+
+func (r UpsertResult) String() string {
+	switch r {
+	case UpsertInserted:
+		return "inserted"
+	case UpsertUpdated:
+		return "updated"
+	}
+	return "skipped"
+}
+
+// OnConflict defines the ON CONFLICT clause of an insert.
+type OnConflict struct {
+	// Columns are the conflict target, e.g., ON CONFLICT (a, b). Either Columns or Constraint is required.
+	Columns []string
+
+	// Constraint is the name of the constraint to use as the target, e.g., ON CONFLICT ON CONSTRAINT name.
+	Constraint string
+
+	// DoNothing skips conflicting rows instead of updating them.
+	DoNothing bool
+
+	// UpdateColumns are the columns updated on conflict, set from EXCLUDED. If empty, all columns in the inserted data
+	// except the conflict Columns are updated.
+	UpdateColumns []string
+
+	// Set contains additional (or overriding) assignments for the update, e.g., "updated" => squirrel.Expr("NOW()").
+	Set map[string]interface{}
+
+	// Where restricts which conflicting rows are updated.
+	Where squirrel.Sqlizer
+}
+
+// Sqlizer returns the ON CONFLICT clause for the inserted data.
+func (c *OnConflict) Sqlizer(setMap map[string]interface{}) squirrel.Sqlizer {
+	return &onConflictSqlizer{conflict: c, setMap: setMap}
+}
+
+// updateColumns returns the columns updated from EXCLUDED, sorted so that the query is stable.
+func (c *OnConflict) updateColumns(setMap map[string]interface{}) []string {
+	if len(c.UpdateColumns) > 0 {
+		return c.UpdateColumns
+	}
+	target := make(map[string]bool, len(c.Columns))
+	for _, col := range c.Columns {
+		target[col] = true
+	}
+	cols := make([]string, 0, len(setMap))
+	for col := range setMap {
+		if target[col] {
+			continue
+		}
+		if _, ok := c.Set[col]; ok {
+			continue
+		}
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+	return cols
+}
+
+type onConflictSqlizer struct {
+	conflict *OnConflict
+	setMap   map[string]interface{}
+}
+
+func (s *onConflictSqlizer) ToSql() (string, []interface{}, error) {
+	c := s.conflict
+
+	var sb strings.Builder
+	sb.WriteString("ON CONFLICT")
+	switch {
+	case c.Constraint != "":
+		sb.WriteString(" ON CONSTRAINT " + c.Constraint)
+	case len(c.Columns) > 0:
+		sb.WriteString(" (" + strings.Join(c.Columns, ", ") + ")")
+	case !c.DoNothing:
+		// DO NOTHING is the only action that does not require a target.
+		return "", nil, ErrUpsertMissingConflict
+	}
+
+	cols := c.updateColumns(s.setMap)
+	if c.DoNothing || (len(cols) == 0 && len(c.Set) == 0) {
+		sb.WriteString(" DO NOTHING")
+		return sb.String(), nil, nil
+	}
+
+	var args []interface{}
+	sets := make([]string, 0, len(cols)+len(c.Set))
+	for _, col := range cols {
+		sets = append(sets, col+" = EXCLUDED."+col)
+	}
+	setCols := make([]string, 0, len(c.Set))
+	for col := range c.Set {
+		setCols = append(setCols, col)
+	}
+	sort.Strings(setCols)
+	for _, col := range setCols {
+		if sqlizer, ok := c.Set[col].(squirrel.Sqlizer); ok {
+			sql, a, err := sqlizer.ToSql()
+			if err != nil {
+				return "", nil, err
+			}
+			sets = append(sets, col+" = "+sql)
+			args = append(args, a...)
+			continue
+		}
+		sets = append(sets, col+" = ?")
+		args = append(args, c.Set[col])
+	}
+	sb.WriteString(" DO UPDATE SET " + strings.Join(sets, ", "))
+
+	if c.Where != nil {
+		sql, a, err := c.Where.ToSql()
+		if err != nil {
+			return "", nil, err
+		}
+		if sql != "" {
+			sb.WriteString(" WHERE " + sql)
+			args = append(args, a...)
+		}
+	}
+	return sb.String(), args, nil
+}
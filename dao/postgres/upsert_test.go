@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateBuilder_UpsertBuilder(t *testing.T) {
+	b := NewUpdateBuilder(NewStatementBuilder()).SetBaseTable("royalty")
+	data := map[string]interface{}{
+		"release_id": "r1",
+		"track_id":   "t1",
+		"amount":     10,
+	}
+
+	tests := []struct {
+		conflict OnConflict
+		sql      string
+		args     []interface{}
+		err      error
+	}{
+		{
+			conflict: OnConflict{Columns: []string{"release_id", "track_id"}},
+			sql: "INSERT INTO royalty (amount,release_id,track_id) VALUES ($1,$2,$3) " +
+				"ON CONFLICT (release_id, track_id) DO UPDATE SET amount = EXCLUDED.amount",
+			args: []interface{}{10, "r1", "t1"},
+		},
+		{
+			conflict: OnConflict{Constraint: "royalty_pkey", DoNothing: true},
+			sql:      "INSERT INTO royalty (amount,release_id,track_id) VALUES ($1,$2,$3) ON CONFLICT ON CONSTRAINT royalty_pkey DO NOTHING",
+			args:     []interface{}{10, "r1", "t1"},
+		},
+		{
+			conflict: OnConflict{DoNothing: true},
+			sql:      "INSERT INTO royalty (amount,release_id,track_id) VALUES ($1,$2,$3) ON CONFLICT DO NOTHING",
+			args:     []interface{}{10, "r1", "t1"},
+		},
+		{
+			conflict: OnConflict{
+				Columns:       []string{"release_id"},
+				UpdateColumns: []string{"amount"},
+				Set: map[string]interface{}{
+					"updated": squirrel.Expr("NOW()"),
+					"source":  "import",
+				},
+				Where: squirrel.Expr("royalty.locked = ?", false),
+			},
+			sql: "INSERT INTO royalty (amount,release_id,track_id) VALUES ($1,$2,$3) " +
+				"ON CONFLICT (release_id) DO UPDATE SET amount = EXCLUDED.amount, source = $4, updated = NOW() " +
+				"WHERE royalty.locked = $5",
+			args: []interface{}{10, "r1", "t1", "import", false},
+		},
+		{
+			conflict: OnConflict{},
+			err:      ErrUpsertMissingConflict,
+		},
+	}
+
+	for i, test := range tests {
+		sql, args, err := b.UpsertBuilder(data, &test.conflict).PlaceholderFormat(squirrel.Dollar).ToSql()
+		if test.err != nil {
+			assert.ErrorIs(t, err, test.err, "[%d]", i)
+			continue
+		}
+		require.NoError(t, err, "[%d]", i)
+		assert.Equal(t, test.sql, sql, "[%d]", i)
+		assert.Equal(t, test.args, args, "[%d]", i)
+	}
+}
@@ -1,27 +1,217 @@
 package postgres
 
 import (
+	"sort"
 	"strings"
 
 	"github.com/Masterminds/squirrel"
 )
 
-// With implements the squirrel.Sqlizer interface for WITH as a prefix.
+// Materialization is the materialization hint of a CTE.
+type Materialization int
+
+const (
+	// MaterializeDefault lets postgres decide whether to materialize the CTE.
+	MaterializeDefault Materialization = iota
+
+	// Materialized forces the CTE to be computed once (AS MATERIALIZED).
+	Materialized
+
+	// NotMaterialized allows the CTE to be inlined into the main query (AS NOT MATERIALIZED).
+	NotMaterialized
+)
+
+func (m Materialization) String() string {
+	switch m {
+	case Materialized:
+		return "MATERIALIZED"
+	case NotMaterialized:
+		return "NOT MATERIALIZED"
+	}
+	return ""
+}
+
+// CTE is a single common table expression within a WITH statement.
+type CTE struct {
+	// Name of the CTE.
+	Name string
+
+	// Columns is the optional column list of the CTE. It is generally required for recursive CTEs.
+	Columns []string
+
+	// Query is the query of the CTE. For recursive CTEs, it is the non-recursive term.
+	Query squirrel.Sqlizer
+
+	// Recursive is the recursive term of the CTE, which can reference the CTE by Name. If set, the query becomes
+	// `Query UNION ALL Recursive` (or UNION if Distinct is set) and the WITH statement becomes WITH RECURSIVE.
+	Recursive squirrel.Sqlizer
+
+	// Distinct uses UNION instead of UNION ALL for recursive CTEs, which discards duplicate rows.
+	Distinct bool
+
+	// Materialization is the materialization hint.
+	Materialization Materialization
+}
+
+// NewRecursiveCTE returns a recursive CTE, e.g., to retrieve a label and all its sub-labels,
+//
+//	NewRecursiveCTE("labels", []string{"id", "parent_id"},
+//	    squirrel.Select("id", "parent_id").From("label").Where("id = ?", id),
+//	    squirrel.Select("l.id", "l.parent_id").From("label l").Join("labels ON labels.id = l.parent_id"),
+//	)
+func NewRecursiveCTE(name string, columns []string, query, recursive squirrel.Sqlizer) CTE {
+	return CTE{
+		Name:      name,
+		Columns:   columns,
+		Query:     query,
+		Recursive: recursive,
+	}
+}
+
+func (c CTE) ToSql() (string, []interface{}, error) {
+	sql, args, err := c.Query.ToSql()
+	if err != nil {
+		return "", nil, err
+	}
+	if c.Recursive != nil {
+		rsql, rargs, err := c.Recursive.ToSql()
+		if err != nil {
+			return "", nil, err
+		}
+		union := " UNION ALL "
+		if c.Distinct {
+			union = " UNION "
+		}
+		sql = sql + union + rsql
+		args = append(args, rargs...)
+	}
+
+	var b strings.Builder
+	b.WriteString(c.Name)
+	if len(c.Columns) > 0 {
+		b.WriteString("(" + strings.Join(c.Columns, ", ") + ")")
+	}
+	b.WriteString(" AS ")
+	if m := c.Materialization.String(); m != "" {
+		b.WriteString(m + " ")
+	}
+	b.WriteString("(" + sql + ")")
+	return b.String(), args, nil
+}
+
+// With implements the squirrel.Sqlizer interface for WITH as a prefix. As a map, the order of the CTEs is not
+// defined, so they are output sorted by name. Use OrderedWith if CTEs need to reference each other.
 type With map[string]squirrel.Sqlizer
 
 func (w With) ToSql() (string, []interface{}, error) {
-	if len(w) == 0 {
+	return w.ordered().ToSql()
+}
+
+// ordered returns the CTEs of the map as an OrderedWith, sorted by name.
+func (w With) ordered() *OrderedWith {
+	names := make([]string, 0, len(w))
+	for k := range w {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	o := &OrderedWith{}
+	for _, k := range names {
+		o.Add(k, w[k])
+	}
+	return o
+}
+
+// OrderedWith implements the squirrel.Sqlizer interface for WITH as a prefix. CTEs are output in the order that they
+// are added, so that later CTEs can reference earlier ones. A nil OrderedWith outputs nothing.
+type OrderedWith struct {
+	CTEs []CTE
+}
+
+// NewOrderedWith creates a With statement containing the CTEs.
+func NewOrderedWith(ctes ...CTE) *OrderedWith {
+	return &OrderedWith{CTEs: ctes}
+}
+
+// Has returns true if a CTE with the name exists.
+func (w *OrderedWith) Has(name string) bool {
+	if w == nil {
+		return false
+	}
+	for _, c := range w.CTEs {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Add adds a CTE with the provided name and query. If a CTE of the same name already exists, it is ignored. See
+// AddCTE.
+func (w *OrderedWith) Add(name string, query squirrel.Sqlizer) *OrderedWith {
+	return w.AddCTE(CTE{Name: name, Query: query})
+}
+
+// AddCTE adds the CTE. If a CTE of the same name already exists, it is ignored. The OrderedWith is returned, or a new
+// one containing the CTE if it is nil.
+func (w *OrderedWith) AddCTE(cte CTE) *OrderedWith {
+	if w == nil {
+		return NewOrderedWith(cte)
+	}
+	if w.Has(cte.Name) {
+		return w
+	}
+	w.CTEs = append(w.CTEs, cte)
+	return w
+}
+
+// Len returns the number of CTEs.
+func (w *OrderedWith) Len() int {
+	if w == nil {
+		return 0
+	}
+	return len(w.CTEs)
+}
+
+// Clone returns a copy of the With statement which can be added to without modifying the original.
+func (w *OrderedWith) Clone() *OrderedWith {
+	c := &OrderedWith{}
+	if w != nil {
+		c.CTEs = append(c.CTEs, w.CTEs...)
+	}
+	return c
+}
+
+// IsRecursive returns true if any of the CTEs is recursive.
+func (w *OrderedWith) IsRecursive() bool {
+	if w == nil {
+		return false
+	}
+	for _, c := range w.CTEs {
+		if c.Recursive != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *OrderedWith) ToSql() (string, []interface{}, error) {
+	if w.Len() == 0 {
 		return "", []interface{}{}, nil
 	}
 	var sqlParts []string
 	var args []interface{}
-	for k, s := range w {
-		p, a, err := s.ToSql()
+	for _, c := range w.CTEs {
+		p, a, err := c.ToSql()
 		if err != nil {
 			return "", nil, err
 		}
-		sqlParts = append(sqlParts, k+" AS ("+p+")")
+		sqlParts = append(sqlParts, p)
 		args = append(args, a...)
 	}
-	return "WITH " + strings.Join(sqlParts, ", "), args, nil
+	prefix := "WITH "
+	if w.IsRecursive() {
+		prefix = "WITH RECURSIVE "
+	}
+	return prefix + strings.Join(sqlParts, ", "), args, nil
 }
@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderedWith(t *testing.T) {
+	var w *OrderedWith
+	sql, args, err := w.ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "", sql)
+	assert.Empty(t, args)
+
+	// Adding to a nil OrderedWith returns a new one.
+	w = w.Add("a", squirrel.Expr("SELECT 1"))
+	assert.Equal(t, 1, w.Len())
+
+	w = NewOrderedWith(NewRecursiveCTE("labels", []string{"id", "parent_id"},
+		squirrel.Select("id", "parent_id").From("label").Where("id = ?", 1),
+		squirrel.Select("l.id", "l.parent_id").From("label l").Join("labels ON labels.id = l.parent_id"),
+	))
+	w.AddCTE(CTE{
+		Name:            "counts",
+		Query:           squirrel.Select("label_id", "count(*)").From("release").Where("type = ?", "Album"),
+		Materialization: NotMaterialized,
+	})
+	w.Add("a", squirrel.Expr("SELECT ?", 2))
+	w.Add("counts", squirrel.Expr("SELECT 3"))
+
+	sql, args, err = w.ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "WITH RECURSIVE labels(id, parent_id) AS "+
+		"(SELECT id, parent_id FROM label WHERE id = ? "+
+		"UNION ALL SELECT l.id, l.parent_id FROM label l JOIN labels ON labels.id = l.parent_id), "+
+		"counts AS NOT MATERIALIZED (SELECT label_id, count(*) FROM release WHERE type = ?), "+
+		"a AS (SELECT ?)", sql)
+	assert.Equal(t, []interface{}{1, "Album", 2}, args)
+}
+
+func TestWith(t *testing.T) {
+	w := With{
+		"b": squirrel.Expr("SELECT ?", 2),
+		"a": squirrel.Expr("SELECT ?", 1),
+	}
+	sql, args, err := w.ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "WITH a AS (SELECT ?), b AS (SELECT ?)", sql)
+	assert.Equal(t, []interface{}{1, 2}, args)
+}
+
+func TestStatementBuilder_With(t *testing.T) {
+	b := NewStatementBuilder()
+	b.AddPrefix("b", squirrel.Expr("SELECT ?", 1))
+	b.AddPrefix("a", squirrel.Expr("SELECT ?", 2))
+	b.AddCTE(CTE{Name: "d", Query: squirrel.Expr("SELECT ?", 3)})
+	b.AddCTE(CTE{Name: "c", Query: squirrel.Expr("SELECT ?", 4)})
+
+	// Names are unique across both prefixes and CTEs.
+	b.AddPrefix("b", squirrel.Expr("SELECT 5"))
+	b.AddPrefix("c", squirrel.Expr("SELECT 6"))
+	b.AddCTE(CTE{Name: "a", Query: squirrel.Expr("SELECT 7")})
+
+	assert.Len(t, b.WithPrefix, 2)
+	assert.Equal(t, 2, b.CTEs.Len())
+
+	sql, args, err := NewSelectBuilder(b).SetFrom("release").Builder("id").ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "WITH d AS (SELECT ?), c AS (SELECT ?), a AS (SELECT ?), b AS (SELECT ?) SELECT id FROM release", sql)
+	assert.Equal(t, []interface{}{3, 4, 2, 1}, args)
+}
+
+func TestUpdateBuilder_WithPrefix(t *testing.T) {
+	b := NewStatementBuilder()
+	b.AddCTE(CTE{
+		Name:            "z",
+		Query:           squirrel.Expr("SELECT ?", 1),
+		Materialization: Materialized,
+	})
+	b.AddPrefix("a", squirrel.Expr("SELECT ?", 2))
+	b.AddJoin("z", "z ON z.id = release.id")
+	b.AddCondition(squirrel.Eq{"a": 3})
+
+	u := NewUpdateBuilder(b).SetBaseTable("release")
+	u.IdColumnName = "id"
+	sql, args, err := u.DeleteBuilder().ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "WITH z AS MATERIALIZED (SELECT ?), a AS (SELECT ?), "+
+		"release_condition AS ( SELECT id FROM release JOIN z ON z.id = release.id WHERE (a = ?)) "+
+		"DELETE FROM release WHERE id IN (SELECT id FROM release_condition)", sql)
+	assert.Equal(t, []interface{}{1, 2, 3}, args)
+
+	// The statement builder is unmodified.
+	assert.Equal(t, 1, b.CTEs.Len())
+	assert.Len(t, b.WithPrefix, 1)
+}
@@ -0,0 +1,111 @@
+package testdao
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/monstercat/golib/dao/postgres"
+)
+
+// NormalizeSQL collapses all whitespace into single spaces and trims the query, so that queries can be compared
+// regardless of formatting. Note that whitespace within string literals is collapsed as well.
+func NormalizeSQL(sql string) string {
+	return strings.Join(strings.Fields(sql), " ")
+}
+
+// ToSql returns the query of the sqlizer using the postgres (dollar) placeholder format.
+func ToSql(s squirrel.Sqlizer) (string, []interface{}, error) {
+	sql, args, err := s.ToSql()
+	if err != nil {
+		return "", nil, err
+	}
+	sql, err = squirrel.Dollar.ReplacePlaceholders(sql)
+	return sql, args, err
+}
+
+// SelectSql returns the query generated by the selector as done by postgres.Selector.Select, including soft deletes,
+// paging, sorting and PreprocessSelect. If no columns are provided, GetCols is used.
+func SelectSql[T any](s *postgres.Selector[T], cols ...string) (string, []interface{}, error) {
+	return ToSql(s.SelectBuilder(cols...))
+}
+
+// AssertQuery asserts that the query matches the SQL (see NormalizeSQL) and arguments.
+func AssertQuery(t testing.TB, q Query, sql string, args ...interface{}) bool {
+	t.Helper()
+	return assertSql(t, q.SQL, q.Args, sql, args)
+}
+
+// AssertQueries asserts that the SQL of the queries received by the provider match, in order.
+func (p *Provider) AssertQueries(t testing.TB, sqls ...string) bool {
+	t.Helper()
+	queries := p.Queries()
+	actual := make([]string, 0, len(queries))
+	for _, q := range queries {
+		actual = append(actual, NormalizeSQL(q.SQL))
+	}
+	expected := make([]string, 0, len(sqls))
+	for _, s := range sqls {
+		expected = append(expected, NormalizeSQL(s))
+	}
+	return assert.Equal(t, expected, actual)
+}
+
+// AssertLast asserts that the last query received by the provider matches the SQL and arguments.
+func (p *Provider) AssertLast(t testing.TB, sql string, args ...interface{}) bool {
+	t.Helper()
+	q, ok := p.Last()
+	if !ok {
+		return assert.Fail(t, "No queries have been received")
+	}
+	return AssertQuery(t, q, sql, args...)
+}
+
+// AssertSqlizer asserts the query generated by the sqlizer, using the postgres placeholder format.
+func AssertSqlizer(t testing.TB, s squirrel.Sqlizer, sql string, args ...interface{}) bool {
+	t.Helper()
+	actual, actualArgs, err := ToSql(s)
+	if !assert.NoError(t, err) {
+		return false
+	}
+	return assertSql(t, actual, actualArgs, sql, args)
+}
+
+// AssertSelect asserts the query generated by the selector for its GetCols, as done by postgres.Selector.Select.
+func AssertSelect[T any](t testing.TB, s *postgres.Selector[T], sql string, args ...interface{}) bool {
+	t.Helper()
+	actual, actualArgs, err := SelectSql(s)
+	if !assert.NoError(t, err) {
+		return false
+	}
+	return assertSql(t, actual, actualArgs, sql, args)
+}
+
+// AssertUpdate asserts the query generated by the update builder for the data.
+func AssertUpdate(
+	t testing.TB,
+	b *postgres.UpdateBuilder,
+	setMap map[string]interface{},
+	sql string,
+	args ...interface{},
+) bool {
+	t.Helper()
+	return AssertSqlizer(t, b.UpdateBuilder(setMap), sql, args...)
+}
+
+// AssertDelete asserts the query generated by the update builder for deletes.
+func AssertDelete(t testing.TB, b *postgres.UpdateBuilder, sql string, args ...interface{}) bool {
+	t.Helper()
+	return AssertSqlizer(t, b.DeleteBuilder(), sql, args...)
+}
+
+func assertSql(t testing.TB, actual string, actualArgs []interface{}, expected string, expectedArgs []interface{}) bool {
+	t.Helper()
+	ok := assert.Equal(t, NormalizeSQL(expected), NormalizeSQL(actual))
+	if len(expectedArgs) == 0 && len(actualArgs) == 0 {
+		return ok
+	}
+	return assert.Equal(t, expectedArgs, actualArgs) && ok
+}
@@ -0,0 +1,22 @@
+// Package testdao provides utilities for testing DAOs built with the postgres sub-package without a database.
+//
+// A Provider implements postgres.DBProvider. It records every query along with its arguments, and returns scripted
+// results. Results are either queued through AddResult, which are consumed in order, or matched against the query
+// through On.
+//
+//	p := testdao.NewProvider()
+//	p.On("FROM release", testdao.Rows([]string{"id", "title"},
+//	    []interface{}{"1", "Release"},
+//	))
+//
+//	s := &postgres.Selector[Release]{
+//	    Provider: p,
+//	    ...
+//	}
+//	xs, err := s.Select()
+//
+//	p.AssertQueries(t, "SELECT id, title FROM release WHERE (id = $1)")
+//
+// To assert the query generated by a selector or builder without executing it, use AssertSelect, AssertUpdate and
+// AssertDelete.
+package testdao
@@ -0,0 +1,165 @@
+package testdao
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+)
+
+// connector opens connections which pass all queries to the Provider.
+type connector struct {
+	p *Provider
+}
+
+func (c *connector) Connect(context.Context) (driver.Conn, error) {
+	return &conn{p: c.p}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return drv{}
+}
+
+// drv is only required to implement driver.Connector. Connections are created through the connector.
+type drv struct{}
+
+func (drv) Open(string) (driver.Conn, error) {
+	return nil, errors.New("testdao: connections can only be opened through a Provider")
+}
+
+type conn struct {
+	p *Provider
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{c: c, query: query}, nil
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return tx{}, nil
+}
+
+// CheckNamedValue accepts all arguments as is, so that they are recorded as passed to the query.
+func (c *conn) CheckNamedValue(*driver.NamedValue) error {
+	return nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r := c.p.run(query, namedValues(args), false)
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	return &rows{result: r}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r := c.p.run(query, namedValues(args), true)
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	return result{r}, nil
+}
+
+func namedValues(args []driver.NamedValue) []interface{} {
+	xs := make([]interface{}, 0, len(args))
+	for _, a := range args {
+		xs = append(xs, a.Value)
+	}
+	return xs
+}
+
+// stmt is only used if a query is explicitly prepared.
+type stmt struct {
+	c     *conn
+	query string
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.c.ExecContext(context.Background(), s.query, toNamed(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.c.QueryContext(context.Background(), s.query, toNamed(args))
+}
+
+func toNamed(args []driver.Value) []driver.NamedValue {
+	xs := make([]driver.NamedValue, 0, len(args))
+	for i, a := range args {
+		xs = append(xs, driver.NamedValue{Ordinal: i + 1, Value: a})
+	}
+	return xs
+}
+
+// tx does nothing, as nothing is stored.
+type tx struct{}
+
+func (tx) Commit() error {
+	return nil
+}
+
+func (tx) Rollback() error {
+	return nil
+}
+
+type result struct {
+	r Result
+}
+
+func (r result) LastInsertId() (int64, error) {
+	return r.r.LastInsertId, nil
+}
+
+func (r result) RowsAffected() (int64, error) {
+	return r.r.RowsAffected, nil
+}
+
+type rows struct {
+	result Result
+	idx    int
+}
+
+func (r *rows) Columns() []string {
+	return r.result.Columns
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.idx >= len(r.result.Rows) {
+		return io.EOF
+	}
+	row := r.result.Rows[r.idx]
+	r.idx++
+	for i := range dest {
+		if i >= len(row) {
+			dest[i] = nil
+			continue
+		}
+		v, err := driver.DefaultParameterConverter.ConvertValue(row[i])
+		if err != nil {
+			return err
+		}
+		dest[i] = v
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package testdao
+
+import (
+	"database/sql"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Query is a query received by the Provider.
+type Query struct {
+	// SQL of the query, with placeholders.
+	SQL string
+
+	// Args are the arguments, as passed to the query.
+	Args []interface{}
+
+	// Exec is true if the query was executed without returning rows.
+	Exec bool
+}
+
+// Result is the scripted result of a query.
+type Result struct {
+	// Columns returned by the query.
+	Columns []string
+
+	// Rows returned by the query. Each row contains a value for each column.
+	Rows [][]interface{}
+
+	// RowsAffected by an exec.
+	RowsAffected int64
+
+	// LastInsertId of an exec.
+	LastInsertId int64
+
+	// Err, if set, is returned by the query instead.
+	Err error
+}
+
+// Rows returns a Result containing the rows.
+func Rows(columns []string, rows ...[]interface{}) Result {
+	return Result{
+		Columns: columns,
+		Rows:    rows,
+	}
+}
+
+// Affected returns a Result for an exec affecting n rows.
+func Affected(n int64) Result {
+	return Result{RowsAffected: n}
+}
+
+// Error returns a Result which fails with err.
+func Error(err error) Result {
+	return Result{Err: err}
+}
+
+type rule struct {
+	contains string
+	result   Result
+}
+
+// Provider is a postgres.DBProvider which records all queries and returns scripted results. It does not require a
+// database. The zero value is not usable; use NewProvider.
+//
+// For each query, the first queued result (see AddResult) is returned. If none are queued, the first rule (see On)
+// which matches the query is returned. Otherwise, no rows are returned and no rows are affected.
+type Provider struct {
+	mu      sync.Mutex
+	queries []Query
+	results []Result
+	rules   []rule
+
+	db *sqlx.DB
+}
+
+// NewProvider creates a Provider.
+func NewProvider() *Provider {
+	p := &Provider{}
+	p.db = sqlx.NewDb(sql.OpenDB(&connector{p: p}), "postgres")
+	return p
+}
+
+// GetDb returns the connection. It is also a sqlx.ExtContext.
+func (p *Provider) GetDb() sqlx.Ext {
+	return p.db
+}
+
+// DB returns the connection, e.g., to begin transactions.
+func (p *Provider) DB() *sqlx.DB {
+	return p.db
+}
+
+// AddResult queues results, which are returned in order for the next queries.
+func (p *Provider) AddResult(rs ...Result) *Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.results = append(p.results, rs...)
+	return p
+}
+
+// On returns the result for all queries containing the string, if no queued results remain.
+func (p *Provider) On(contains string, r Result) *Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rules = append(p.rules, rule{contains: contains, result: r})
+	return p
+}
+
+// Queries returns the queries received so far.
+func (p *Provider) Queries() []Query {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	xs := make([]Query, len(p.queries))
+	copy(xs, p.queries)
+	return xs
+}
+
+// Last returns the last query received. It returns false if there are no queries.
+func (p *Provider) Last() (Query, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.queries) == 0 {
+		return Query{}, false
+	}
+	return p.queries[len(p.queries)-1], true
+}
+
+// Reset clears the recorded queries, queued results and rules.
+func (p *Provider) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.queries = nil
+	p.results = nil
+	p.rules = nil
+}
+
+// run records the query and returns its result.
+func (p *Provider) run(query string, args []interface{}, exec bool) Result {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.queries = append(p.queries, Query{
+		SQL:  query,
+		Args: args,
+		Exec: exec,
+	})
+	if len(p.results) > 0 {
+		r := p.results[0]
+		p.results = p.results[1:]
+		return r
+	}
+	for _, r := range p.rules {
+		if strings.Contains(query, r.contains) {
+			return r.result
+		}
+	}
+	return Result{}
+}
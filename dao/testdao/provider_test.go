@@ -0,0 +1,139 @@
+package testdao
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/monstercat/golib/dao/daohelpers"
+	"github.com/monstercat/golib/dao/postgres"
+)
+
+type release struct {
+	Id    string
+	Title string
+	Plays int
+}
+
+func newSelector(p *Provider) *postgres.Selector[release] {
+	b := postgres.NewStatementBuilder()
+	return &postgres.Selector[release]{
+		QueryBuilder: postgres.NewSelectBuilder(b).SetFrom("release"),
+		Provider:     p,
+		GetCols:      []string{"id", "title", "plays"},
+		Scanner: postgres.ScannerFunc[release](func(row squirrel.RowScanner) (release, error) {
+			var r release
+			err := row.Scan(&r.Id, &r.Title, &r.Plays)
+			return r, err
+		}),
+	}
+}
+
+func TestProvider_Selector(t *testing.T) {
+	p := NewProvider()
+	p.On("SELECT id, title, plays", Rows([]string{"id", "title", "plays"},
+		[]interface{}{"1", "First", 10},
+		[]interface{}{"2", "Second", int64(20)},
+	))
+	p.On("COUNT(*)", Rows([]string{"count"}, []interface{}{2}))
+
+	s := newSelector(p)
+	s.QueryBuilder.AddCondition(squirrel.Eq{"label_id": "abc"})
+	s.QueryBuilder.SetLimit(10).SetOffset(20)
+
+	xs, err := s.Select()
+	assert.NoError(t, err)
+	assert.Equal(t, []release{
+		{Id: "1", Title: "First", Plays: 10},
+		{Id: "2", Title: "Second", Plays: 20},
+	}, xs)
+
+	total, err := s.Total()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), total)
+
+	p.AddResult(Error(errors.New("failed")))
+	_, err = s.Get()
+	assert.EqualError(t, err, "failed")
+
+	p.AssertQueries(t,
+		"SELECT id, title, plays FROM release WHERE (label_id = $1) LIMIT 10 OFFSET 20",
+		"SELECT COUNT(*) FROM release WHERE (label_id = $1)",
+		"SELECT id, title, plays FROM release WHERE (label_id = $1)",
+	)
+	p.AssertLast(t, "SELECT id, title, plays FROM release WHERE (label_id = $1)", "abc")
+}
+
+func TestProvider_Updater(t *testing.T) {
+	p := NewProvider()
+	u := postgres.NewUpdater[string](postgres.NewStatementBuilder(), "release").
+		SetProvider(p).
+		SetIdColumn("id")
+	u.Set("title", "hello")
+
+	p.AddResult(Rows([]string{"id"}, []interface{}{"abc"}))
+	id, err := u.Insert()
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", id)
+
+	u.QueryBuilder.AddCondition(squirrel.Eq{"id": "abc"})
+	assert.ErrorIs(t, u.Update(), daohelpers.ErrNoUpdatePerformed)
+
+	p.AddResult(Affected(1))
+	assert.NoError(t, u.Update())
+
+	queries := p.Queries()
+	assert.Len(t, queries, 3)
+	AssertQuery(t, queries[0], "INSERT INTO release (title) VALUES ($1) RETURNING id", "hello")
+	assert.True(t, queries[2].Exec)
+
+	p.Reset()
+	_, ok := p.Last()
+	assert.False(t, ok)
+}
+
+func TestAssertBuilders(t *testing.T) {
+	b := postgres.NewStatementBuilder()
+	b.AddJoin("artist", "artist ON artist.id = release.artist_id")
+	b.AddCondition(squirrel.Eq{"artist.name": "Artist"})
+	b.AddSort([]string{"release.title ASC"})
+	b.SetLimit(5)
+
+	s := &postgres.Selector[string]{
+		QueryBuilder: postgres.NewSelectBuilder(b).SetFrom("release"),
+		GetCols:      []string{postgres.TablePlaceholder + ".id"},
+		PreprocessSelect: func(from string, qry squirrel.SelectBuilder) squirrel.SelectBuilder {
+			return qry.Where(squirrel.Eq{from + ".status": "published"})
+		},
+	}
+	s.SetSoftDelete("deleted_at")
+	AssertSelect(t, s, `
+		SELECT release.id
+		FROM release
+		JOIN artist ON artist.id = release.artist_id
+		WHERE (artist.name = $1) AND release.deleted_at IS NULL AND release.status = $2
+		ORDER BY release.title ASC
+		LIMIT 5`,
+		"Artist", "published",
+	)
+
+	s.SetIncludeDeleted(true)
+	sql, args, err := SelectSql(s, "COUNT(*)")
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT COUNT(*) FROM release JOIN artist ON artist.id = release.artist_id "+
+		"WHERE (artist.name = $1) AND release.status = $2 ORDER BY release.title ASC LIMIT 5", NormalizeSQL(sql))
+	assert.Equal(t, []interface{}{"Artist", "published"}, args)
+
+	u := postgres.NewUpdateBuilder(b).SetBaseTable("release")
+	u.IdColumnName = "id"
+	AssertDelete(t, u, `
+		WITH release_condition AS (
+			SELECT id FROM release
+			JOIN artist ON artist.id = release.artist_id
+			WHERE (artist.name = $1))
+		DELETE FROM release WHERE id IN (SELECT id FROM release_condition)`,
+		"Artist",
+	)
+}
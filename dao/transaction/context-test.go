@@ -16,3 +16,18 @@ func (t *TestContext) Rollback() {
 func (t *TestContext) Commit() {
 	t.Called()
 }
+
+// TestErrorContext is a test transaction context. It implements ErrorContext.
+type TestErrorContext struct {
+	TestContext
+}
+
+// TryRollback is Rollback, returning any error.
+func (t *TestErrorContext) TryRollback() error {
+	return t.Called().Error(0)
+}
+
+// TryCommit is Commit, returning any error.
+func (t *TestErrorContext) TryCommit() error {
+	return t.Called().Error(0)
+}
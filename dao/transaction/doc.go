@@ -26,4 +26,9 @@
 //	}
 //
 // A helper method GetContext is created to help with this logic.
+//
+// Contexts whose Commit or Rollback can fail should also implement
+// ErrorContext, so that Tx is able to return the error. See
+// postgres.TransactionContext for a Context which shares a single sqlx.Tx
+// between DAOs.
 package transaction
@@ -1,5 +1,12 @@
 package transaction
 
+import (
+	"fmt"
+	"sort"
+
+	"github.com/monstercat/golib/errors"
+)
+
 // Context defines a group of transactions in the same type of service (e.g., postgers; s3) which can
 // Rollback or Commit.
 type Context interface {
@@ -10,41 +17,265 @@ type Context interface {
 	Commit()
 }
 
+// ErrorContext is an optional interface for a Context whose Rollback or Commit can fail. If implemented, Transaction
+// uses TryRollback and TryCommit instead of Rollback and Commit.
+type ErrorContext interface {
+	Context
+
+	// TryRollback is Rollback, returning any error.
+	TryRollback() error
+
+	// TryCommit is Commit, returning any error.
+	TryCommit() error
+}
+
+// Preparer is an optional interface for a Context which needs to be prepared before any context commits (i.e., the
+// first phase of a two-phase commit). If any context fails to prepare, all contexts are rolled back.
+type Preparer interface {
+	Prepare() error
+}
+
+// Prioritizer is an optional interface for a Context which defines its commit order. Contexts are committed in
+// ascending order of priority, then in the order they were registered. Contexts which do not implement it have a
+// priority of 0.
+type Prioritizer interface {
+	Priority() int
+}
+
+// Compensator is an optional interface for a Context which is able to undo its changes after being committed. If a
+// context fails to commit, the contexts which have already committed are compensated (e.g., deleting files which
+// have already been written).
+type Compensator interface {
+	Compensate() error
+}
+
+// Nester is an optional interface for a Context which supports nested transactions (e.g., savepoints). See
+// Transaction.Tx.
+type Nester interface {
+	// Nest returns a context which commits or rolls back only the changes made through it. Changes are only made
+	// permanent once the original context commits.
+	Nest() Context
+}
+
 // Transaction defines a single transaction
 type Transaction struct {
 	// Contexts are what is used to retrieve any objects required to be stored within the
 	Contexts map[string]Context
+
+	// Keys of Contexts in the order they were registered.
+	order []string
+
+	// Compensation hooks added through OnCompensate.
+	compensations []func() error
+
+	// parent is the enclosing transaction, if nested.
+	parent *Transaction
 }
 
-func (t *Transaction) Rollback() {
-	for _, c := range t.Contexts {
-		c.Rollback()
+// Register adds the context under the key. Contexts are committed in the order they are registered (see Prioritizer)
+// and rolled back in reverse. Contexts added directly to Contexts are committed after registered ones, sorted by key.
+func (t *Transaction) Register(key string, c Context) {
+	if t.Contexts == nil {
+		t.Contexts = make(map[string]Context)
+	}
+	if _, ok := t.Contexts[key]; !ok {
+		t.order = append(t.order, key)
 	}
+	t.Contexts[key] = c
+}
+
+// OnCompensate adds a hook which is called if the transaction does not commit, whether it is rolled back or fails to
+// prepare or commit. This is useful for undoing changes made outside any context, such as deleting uploaded files.
+// Hooks are called in reverse order, after the contexts are rolled back and compensated.
+//
+// For a nested transaction, the hooks are moved to the enclosing transaction once the nested one commits, as its
+// changes are only permanent once the enclosing transaction commits.
+func (t *Transaction) OnCompensate(fn func() error) {
+	t.compensations = append(t.compensations, fn)
+}
+
+// orderedKeys returns the keys of the contexts in commit order.
+func (t *Transaction) orderedKeys() []string {
+	keys := make([]string, 0, len(t.Contexts))
+	seen := make(map[string]bool, len(t.Contexts))
+	for _, k := range t.order {
+		if _, ok := t.Contexts[k]; ok && !seen[k] {
+			keys = append(keys, k)
+			seen[k] = true
+		}
+	}
+	var rest []string
+	for k := range t.Contexts {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	keys = append(keys, rest...)
+
+	priority := func(k string) int {
+		if p, ok := t.Contexts[k].(Prioritizer); ok {
+			return p.Priority()
+		}
+		return 0
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return priority(keys[i]) < priority(keys[j])
+	})
+	return keys
+}
+
+func (t *Transaction) Rollback() {
+	_ = t.TryRollback()
 }
 
 func (t *Transaction) Commit() {
-	for _, c := range t.Contexts {
-		c.Commit()
+	_ = t.TryCommit()
+}
+
+// TryRollback rolls back all contexts in reverse commit order. All contexts are rolled back regardless of errors,
+// which are returned as errors.Errors.
+func (t *Transaction) TryRollback() error {
+	var errs errors.Errors
+	t.rollback(t.orderedKeys(), &errs)
+	t.runHooks(&errs)
+	return errs.Err()
+}
+
+// TryCommit commits all contexts in order.
+//
+// First, contexts implementing Preparer are prepared. If any fail, all contexts are rolled back. Then, contexts are
+// committed. If one fails, it and the remaining contexts are rolled back, so that the failed context releases its
+// resources, and the contexts which have already committed are compensated. The hooks added through OnCompensate are called in either case.
+//
+// All errors encountered are returned as errors.Errors.
+func (t *Transaction) TryCommit() error {
+	var errs errors.Errors
+	keys := t.orderedKeys()
+
+	for _, k := range keys {
+		p, ok := t.Contexts[k].(Preparer)
+		if !ok {
+			continue
+		}
+		if err := p.Prepare(); err != nil {
+			errs.AddError(fmt.Errorf("prepare %s: %w", k, err))
+			t.rollback(keys, &errs)
+			t.runHooks(&errs)
+			return errs
+		}
 	}
+
+	for i, k := range keys {
+		err := tryCommit(t.Contexts[k])
+		if err == nil {
+			continue
+		}
+		errs.AddError(fmt.Errorf("commit %s: %w", k, err))
+		t.rollback(keys[i:], &errs)
+		t.compensate(keys[:i], &errs)
+		t.runHooks(&errs)
+		return errs
+	}
+	return nil
+}
+
+// rollback rolls back the contexts in reverse order.
+func (t *Transaction) rollback(keys []string, errs *errors.Errors) {
+	for i := len(keys) - 1; i >= 0; i-- {
+		if err := tryRollback(t.Contexts[keys[i]]); err != nil {
+			errs.AddError(fmt.Errorf("rollback %s: %w", keys[i], err))
+		}
+	}
+}
+
+// compensate compensates the contexts in reverse order.
+func (t *Transaction) compensate(keys []string, errs *errors.Errors) {
+	for i := len(keys) - 1; i >= 0; i-- {
+		c, ok := t.Contexts[keys[i]].(Compensator)
+		if !ok {
+			continue
+		}
+		if err := c.Compensate(); err != nil {
+			errs.AddError(fmt.Errorf("compensate %s: %w", keys[i], err))
+		}
+	}
+}
+
+// runHooks runs the compensation hooks in reverse order.
+func (t *Transaction) runHooks(errs *errors.Errors) {
+	for i := len(t.compensations) - 1; i >= 0; i-- {
+		if err := t.compensations[i](); err != nil {
+			errs.AddError(fmt.Errorf("compensate: %w", err))
+		}
+	}
+}
+
+func tryRollback(c Context) error {
+	if ec, ok := c.(ErrorContext); ok {
+		return ec.TryRollback()
+	}
+	c.Rollback()
+	return nil
+}
+
+func tryCommit(c Context) error {
+	if ec, ok := c.(ErrorContext); ok {
+		return ec.TryCommit()
+	}
+	c.Commit()
+	return nil
 }
 
 // Tx provides the transaction for wrapping of functions. Functions that wish to sue the function should take in the
 // Transaction that is passed as the argument.
+//
+// If the function fails, its error is returned after rolling back. Otherwise, any error from committing is returned.
 func Tx(fn func(tx *Transaction) error) error {
-	tx := &Transaction{
+	return run(&Transaction{
 		Contexts: make(map[string]Context),
-	}
+	}, fn)
+}
+
+func run(tx *Transaction, fn func(tx *Transaction) error) error {
 	if err := fn(tx); err != nil {
-		tx.Rollback()
+		tx.TryRollback()
+		return err
+	}
+	return tx.TryCommit()
+}
+
+// Tx runs the function in a transaction nested within this one. Contexts which implement Nester are nested, so that a
+// failure of the function only rolls back the changes made within it, leaving this transaction usable. Other contexts
+// are shared with this transaction and are only committed or rolled back with it.
+func (t *Transaction) Tx(fn func(tx *Transaction) error) error {
+	nested := &Transaction{
+		Contexts: make(map[string]Context),
+		parent:   t,
+	}
+	if err := run(nested, fn); err != nil {
 		return err
 	}
-	tx.Commit()
+	t.compensations = append(t.compensations, nested.compensations...)
 	return nil
 }
 
+// TxWith runs the function in a transaction nested within tx, or in a new transaction if tx is nil. This allows
+// functions which are transactional on their own to be composed.
+func TxWith(tx *Transaction, fn func(tx *Transaction) error) error {
+	if tx == nil {
+		return Tx(fn)
+	}
+	return tx.Tx(fn)
+}
+
 // GetContext is a helper method to retrieve a context of a certain type from
 // a transaction, associated with a specific key. If it does not exist, it will
-// be created through the provided create method.
+// be created through the provided create method and registered.
+//
+// For a nested transaction, the context is retrieved from (or created in) the
+// outermost transaction. If it implements Nester, a nested context is
+// registered instead.
 func GetContext[T Context](tx *Transaction, key string, create func() T) T {
 	m, ok := tx.Contexts[key]
 	if ok {
@@ -54,7 +285,21 @@ func GetContext[T Context](tx *Transaction, key string, create func() T) T {
 		}
 	}
 
+	if tx.parent != nil {
+		pv := GetContext[T](tx.parent, key, create)
+		n, ok := Context(pv).(Nester)
+		if !ok {
+			return pv
+		}
+		v, ok := n.Nest().(T)
+		if !ok {
+			return pv
+		}
+		tx.Register(key, v)
+		return v
+	}
+
 	v := create()
-	tx.Contexts[key] = v
+	tx.Register(key, v)
 	return v
 }
@@ -2,6 +2,7 @@ package transaction
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -43,3 +44,225 @@ func TestTx(t *testing.T) {
 	assert.NotNil(t, err)
 	ctx.AssertExpectations(t)
 }
+
+func TestTx_CommitError(t *testing.T) {
+	commitErr := errors.New("commit failed")
+	ctx := &TestErrorContext{}
+	ctx.On("TryCommit").Return(commitErr)
+
+	// The context which failed to commit is rolled back to release its resources.
+	ctx.On("TryRollback").Return(nil)
+
+	err := Tx(func(tx *Transaction) error {
+		_ = GetContext[*TestErrorContext](tx, "test", func() *TestErrorContext {
+			return ctx
+		})
+		return nil
+	})
+	assert.ErrorIs(t, err, commitErr)
+	ctx.AssertExpectations(t)
+}
+
+// recordingContext records the operations performed on it.
+type recordingContext struct {
+	name       string
+	priority   int
+	log        *[]string
+	prepare    error
+	commit     error
+	compensate error
+}
+
+func (c *recordingContext) Rollback() { *c.log = append(*c.log, "rollback "+c.name) }
+func (c *recordingContext) Commit()   { _ = c.TryCommit() }
+func (c *recordingContext) TryRollback() error {
+	c.Rollback()
+	return nil
+}
+func (c *recordingContext) TryCommit() error {
+	*c.log = append(*c.log, "commit "+c.name)
+	return c.commit
+}
+func (c *recordingContext) Prepare() error {
+	*c.log = append(*c.log, "prepare "+c.name)
+	return c.prepare
+}
+func (c *recordingContext) Priority() int { return c.priority }
+func (c *recordingContext) Compensate() error {
+	*c.log = append(*c.log, "compensate "+c.name)
+	return c.compensate
+}
+
+func TestTransaction_TryCommit(t *testing.T) {
+	commitErr := errors.New("commit failed")
+	prepareErr := errors.New("prepare failed")
+
+	tests := []struct {
+		contexts []*recordingContext
+		log      []string
+		err      error
+	}{
+		{
+			contexts: []*recordingContext{
+				{name: "c"},
+				{name: "a", priority: -1},
+				{name: "b"},
+			},
+			log: []string{
+				"prepare a", "prepare c", "prepare b",
+				"commit a", "commit c", "commit b",
+			},
+		},
+		{
+			contexts: []*recordingContext{
+				{name: "a"},
+				{name: "b", prepare: prepareErr},
+				{name: "c"},
+			},
+			log: []string{
+				"prepare a", "prepare b",
+				"rollback c", "rollback b", "rollback a",
+				"hook",
+			},
+			err: prepareErr,
+		},
+		{
+			contexts: []*recordingContext{
+				{name: "a"},
+				{name: "b"},
+				{name: "c", commit: commitErr},
+				{name: "d"},
+			},
+			log: []string{
+				"prepare a", "prepare b", "prepare c", "prepare d",
+				"commit a", "commit b", "commit c",
+				"rollback d", "rollback c",
+				"compensate b", "compensate a",
+				"hook",
+			},
+			err: commitErr,
+		},
+	}
+
+	for i, test := range tests {
+		var log []string
+		tx := &Transaction{}
+		for _, c := range test.contexts {
+			c.log = &log
+			tx.Register(c.name, c)
+		}
+		tx.OnCompensate(func() error {
+			log = append(log, "hook")
+			return nil
+		})
+
+		err := tx.TryCommit()
+		if test.err == nil {
+			assert.NoError(t, err, "[%d]", i)
+		} else {
+			assert.ErrorIs(t, err, test.err, "[%d]", i)
+		}
+		assert.Equal(t, test.log, log, "[%d]", i)
+	}
+}
+
+// nestingContext is a recordingContext which supports nesting.
+type nestingContext struct {
+	recordingContext
+	depth int
+}
+
+func (c *nestingContext) Nest() Context {
+	n := &nestingContext{depth: c.depth + 1}
+	n.name = fmt.Sprintf("%s.%d", c.name, n.depth)
+	n.log = c.log
+	return n
+}
+
+func TestTransaction_Tx(t *testing.T) {
+	var log []string
+	create := func() *nestingContext {
+		c := &nestingContext{}
+		c.name = "pg"
+		c.log = &log
+		return c
+	}
+	innerErr := errors.New("inner")
+
+	err := TxWith(nil, func(tx *Transaction) error {
+		outer := GetContext[*nestingContext](tx, "pg", create)
+
+		// A failed nested transaction only rolls back its own context.
+		err := TxWith(tx, func(tx *Transaction) error {
+			inner := GetContext[*nestingContext](tx, "pg", create)
+			assert.NotSame(t, outer, inner)
+			assert.Equal(t, 1, inner.depth)
+			return innerErr
+		})
+		assert.ErrorIs(t, err, innerErr)
+
+		// Contexts created within a nested transaction belong to the outermost one.
+		return tx.Tx(func(tx *Transaction) error {
+			return tx.Tx(func(tx *Transaction) error {
+				GetContext[*TestContext](tx, "other", func() *TestContext {
+					c := &TestContext{}
+					c.On("Commit").Return()
+					return c
+				})
+				assert.Equal(t, 2, GetContext[*nestingContext](tx, "pg", create).depth)
+				return nil
+			})
+		})
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"rollback pg.1",
+		"prepare pg.1.2", "commit pg.1.2",
+		"prepare pg.1", "commit pg.1",
+		"prepare pg", "commit pg",
+	}, log)
+}
+
+func TestTransaction_TxCompensate(t *testing.T) {
+	var log []string
+	hook := func(name string) func() error {
+		return func() error {
+			log = append(log, name)
+			return nil
+		}
+	}
+	outerErr := errors.New("outer")
+
+	err := Tx(func(tx *Transaction) error {
+		tx.OnCompensate(hook("outer"))
+
+		// The inner transaction commits, but its changes are undone as the outer one fails.
+		require.NoError(t, tx.Tx(func(tx *Transaction) error {
+			tx.OnCompensate(hook("inner 1"))
+			return tx.Tx(func(tx *Transaction) error {
+				tx.OnCompensate(hook("inner 2"))
+				return nil
+			})
+		}))
+
+		// A failed inner transaction runs its own hooks immediately.
+		assert.Error(t, tx.Tx(func(tx *Transaction) error {
+			tx.OnCompensate(hook("failed"))
+			return errors.New("failed")
+		}))
+		assert.Equal(t, []string{"failed"}, log)
+		return outerErr
+	})
+	assert.ErrorIs(t, err, outerErr)
+	assert.Equal(t, []string{"failed", "inner 2", "inner 1", "outer"}, log)
+
+	// Hooks are not called if the outer transaction commits.
+	log = nil
+	require.NoError(t, Tx(func(tx *Transaction) error {
+		return tx.Tx(func(tx *Transaction) error {
+			tx.OnCompensate(hook("inner"))
+			return nil
+		})
+	}))
+	assert.Empty(t, log)
+}
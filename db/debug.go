@@ -1,6 +1,7 @@
 package dbutil
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/Masterminds/squirrel"
@@ -17,3 +18,8 @@ func DebugQueryPieces(sql string, args []interface{}, err error) {
 	}
 	fmt.Println("err", err)
 }
+
+// DebugQueryHook is a QueryHook which prints every query through DebugQueryPieces.
+var DebugQueryHook = QueryHookFunc(func(_ context.Context, e *QueryEvent) {
+	DebugQueryPieces(e.SQL, e.Args, e.Err)
+})
@@ -1,6 +1,7 @@
 package dbutil
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/Masterminds/squirrel"
@@ -12,7 +13,10 @@ func Get(db sqlx.Queryer, val interface{}, qry squirrel.SelectBuilder) error {
 	if err != nil {
 		return err
 	}
-	return sqlx.Get(db, val, sql, args...)
+	_, done := StartQuery(context.Background(), OperationQueryRow, sql, args)
+	err = sqlx.Get(db, val, sql, args...)
+	done(-1, err)
+	return err
 }
 
 func Select(db sqlx.Queryer, slice interface{}, qry squirrel.SelectBuilder) error {
@@ -20,7 +24,10 @@ func Select(db sqlx.Queryer, slice interface{}, qry squirrel.SelectBuilder) erro
 	if err != nil {
 		return err
 	}
-	return sqlx.Select(db, slice, sql, args...)
+	_, done := StartQuery(context.Background(), OperationQuery, sql, args)
+	err = sqlx.Select(db, slice, sql, args...)
+	done(-1, err)
+	return err
 }
 
 func Exists(db sqlx.Queryer, qry squirrel.SelectBuilder) (bool, error) {
@@ -0,0 +1,91 @@
+package dbutil
+
+import (
+	"context"
+	"time"
+
+	"github.com/monstercat/golib/logger"
+)
+
+// MetricHook is a QueryHook which logs the duration of every query as a logger.MetricEntry, in milliseconds.
+type MetricHook struct {
+	Logger logger.Logger
+
+	// Name of the metric. Defaults to query_duration.
+	Name string
+
+	// Namespace of the metric.
+	Namespace []string
+
+	// Severity of the log. Defaults to logger.SeverityInfo.
+	Severity logger.Severity
+}
+
+func (h *MetricHook) BeforeQuery(ctx context.Context, _ *QueryEvent) context.Context {
+	return ctx
+}
+
+func (h *MetricHook) AfterQuery(_ context.Context, e *QueryEvent) {
+	name := h.Name
+	if name == "" {
+		name = "query_duration"
+	}
+	severity := h.Severity
+	if severity == "" {
+		severity = logger.SeverityInfo
+	}
+	h.Logger.Log(severity, &logger.MetricEntry{
+		Type:      logger.TypeMetric,
+		Name:      name,
+		Namespace: h.Namespace,
+		Value:     int(e.Duration.Milliseconds()),
+		Unit:      "ms",
+		Context:   queryContext(e, false),
+	})
+}
+
+// SlowQueryHook is a QueryHook which logs queries which take at least Threshold, along with their arguments.
+type SlowQueryHook struct {
+	Logger logger.Logger
+
+	// Threshold is the minimum duration of the logged queries.
+	Threshold time.Duration
+
+	// Severity of the log. Defaults to logger.SeverityWarning.
+	Severity logger.Severity
+}
+
+func (h *SlowQueryHook) BeforeQuery(ctx context.Context, _ *QueryEvent) context.Context {
+	return ctx
+}
+
+func (h *SlowQueryHook) AfterQuery(_ context.Context, e *QueryEvent) {
+	if e.Duration < h.Threshold {
+		return
+	}
+	severity := h.Severity
+	if severity == "" {
+		severity = logger.SeverityWarning
+	}
+	h.Logger.Log(severity, logger.NewContextualPayload("Slow query").Add(queryContext(e, true)))
+}
+
+// queryContext returns the log context for the event. The arguments are only included if requested, as they may be
+// large.
+func queryContext(e *QueryEvent, withArgs bool) map[string]interface{} {
+	m := map[string]interface{}{
+		"Operation": string(e.Operation),
+		"SQL":       e.SQL,
+		"Duration":  e.Duration.String(),
+	}
+	if withArgs {
+		m["Args"] = e.Args
+	}
+	if e.RowsAffected >= 0 {
+		m["RowsAffected"] = e.RowsAffected
+	}
+	if e.Err != nil {
+		m["Error"] = e.Err.Error()
+	}
+	return m
+}
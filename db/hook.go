@@ -0,0 +1,184 @@
+package dbutil
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// QueryOperation is the type of query being executed.
+type QueryOperation string
+
+const (
+	OperationQuery    QueryOperation = "query"
+	OperationQueryRow QueryOperation = "query_row"
+	OperationExec     QueryOperation = "exec"
+)
+
+// RedactedArg replaces arguments which have been redacted.
+const RedactedArg = "[REDACTED]"
+
+// QueryEvent describes a query passed to QueryHooks.
+type QueryEvent struct {
+	Operation QueryOperation
+
+	// SQL is the query, with placeholders.
+	SQL string
+
+	// Args are the arguments of the query, after redaction (see SetArgRedactor).
+	Args []interface{}
+
+	// Start is the time the query started.
+	Start time.Time
+
+	// Duration of the query. Only set after the query. For queries returning rows, it is the time until the rows are
+	// returned, not until they have been iterated through.
+	Duration time.Duration
+
+	// RowsAffected is the number of rows affected by an exec. It is -1 if unknown.
+	RowsAffected int64
+
+	// Err is the error of the query, if any. Only set after the query.
+	Err error
+}
+
+// QueryHook is invoked before and after every instrumented query. This includes queries executed by Get, Select,
+// Exists and QuickRows, the pgutil helpers, and the Selector and Updater in dao/postgres.
+type QueryHook interface {
+	// BeforeQuery is called before the query is executed. The returned context is passed to AfterQuery and, where
+	// supported, to the query itself.
+	BeforeQuery(ctx context.Context, e *QueryEvent) context.Context
+
+	// AfterQuery is called after the query has been executed.
+	AfterQuery(ctx context.Context, e *QueryEvent)
+}
+
+// QueryHookFunc is a QueryHook which is only called after queries.
+type QueryHookFunc func(ctx context.Context, e *QueryEvent)
+
+func (f QueryHookFunc) BeforeQuery(ctx context.Context, _ *QueryEvent) context.Context {
+	return ctx
+}
+
+func (f QueryHookFunc) AfterQuery(ctx context.Context, e *QueryEvent) {
+	f(ctx, e)
+}
+
+// ArgRedactor returns the arguments of the query which can be passed to hooks. It must not modify args.
+type ArgRedactor func(query string, args []interface{}) []interface{}
+
+// RedactAllArgs replaces all arguments with RedactedArg.
+func RedactAllArgs(_ string, args []interface{}) []interface{} {
+	xs := make([]interface{}, len(args))
+	for i := range xs {
+		xs[i] = RedactedArg
+	}
+	return xs
+}
+
+var (
+	hooksMu     sync.RWMutex
+	queryHooks  []QueryHook
+	argRedactor ArgRedactor
+)
+
+// AddQueryHook adds hooks which are invoked for every instrumented query.
+func AddQueryHook(hooks ...QueryHook) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	queryHooks = append(queryHooks, hooks...)
+}
+
+// ClearQueryHooks removes all hooks.
+func ClearQueryHooks() {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	queryHooks = nil
+}
+
+// SetArgRedactor sets the redactor which is applied to the arguments before they are passed to hooks. If nil, the
+// arguments are passed as is.
+func SetArgRedactor(r ArgRedactor) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	argRedactor = r
+}
+
+// QueryHooks returns a copy of the registered hooks.
+func QueryHooks() []QueryHook {
+	hooksMu.RLock()
+	defer hooksMu.RUnlock()
+	return append([]QueryHook(nil), queryHooks...)
+}
+
+// StartQuery notifies the hooks that the query is starting. The returned function must be called with the rows affected
+// (-1 if unknown) and error once it is done. If no hooks are registered, nothing is done.
+//
+// Queries executed outside this package can be instrumented through it, e.g.,
+//
+//	ctx, done := StartQuery(ctx, OperationExec, query, args)
+//	res, err := db.ExecContext(ctx, query, args...)
+//	done(ResultRowsAffected(res), err)
+func StartQuery(
+	ctx context.Context,
+	op QueryOperation,
+	query string,
+	args []interface{},
+) (context.Context, func(rowsAffected int64, err error)) {
+	hooksMu.RLock()
+	hooks, redactor := queryHooks, argRedactor
+	hooksMu.RUnlock()
+	if len(hooks) == 0 {
+		return ctx, func(int64, error) {}
+	}
+
+	if redactor != nil {
+		args = redactor(query, args)
+	}
+	e := &QueryEvent{
+		Operation:    op,
+		SQL:          query,
+		Args:         args,
+		Start:        time.Now(),
+		RowsAffected: -1,
+	}
+	for _, h := range hooks {
+		ctx = h.BeforeQuery(ctx, e)
+	}
+	return ctx, func(rowsAffected int64, err error) {
+		e.Duration = time.Since(e.Start)
+		e.RowsAffected = rowsAffected
+		e.Err = err
+		for _, h := range hooks {
+			h.AfterQuery(ctx, e)
+		}
+	}
+}
+
+// ResultRowsAffected returns the rows affected of the result, or -1 if it is not available.
+func ResultRowsAffected(res sql.Result) int64 {
+	if res == nil {
+		return -1
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// HookedRow is a squirrel.RowScanner which notifies the hooks once it is scanned, as errors from QueryRow are only
+// returned through Scan. If Scan is never called, AfterQuery is never fired.
+type HookedRow struct {
+	Row  squirrel.RowScanner
+	Done func(rowsAffected int64, err error)
+}
+
+func (r *HookedRow) Scan(dest ...interface{}) error {
+	err := r.Row.Scan(dest...)
+	r.Done(-1, err)
+	return err
+}
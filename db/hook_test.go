@@ -0,0 +1,81 @@
+package dbutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/monstercat/golib/logger"
+)
+
+type recordingLogger struct {
+	logs []interface{}
+}
+
+func (l *recordingLogger) Log(_ logger.Severity, payload interface{}) {
+	l.logs = append(l.logs, payload)
+}
+
+func TestStartQuery(t *testing.T) {
+	defer ClearQueryHooks()
+	defer SetArgRedactor(nil)
+
+	// Without hooks, nothing is done.
+	_, done := StartQuery(context.Background(), OperationExec, "SELECT 1", nil)
+	done(-1, nil)
+
+	var events []QueryEvent
+	l := &recordingLogger{}
+	AddQueryHook(
+		QueryHookFunc(func(_ context.Context, e *QueryEvent) {
+			events = append(events, *e)
+		}),
+		&MetricHook{Logger: l},
+		&SlowQueryHook{Logger: l, Threshold: time.Hour},
+	)
+	SetArgRedactor(RedactAllArgs)
+
+	// The returned hooks are a copy.
+	hooks := QueryHooks()
+	hooks[0] = nil
+	if QueryHooks()[0] == nil {
+		t.Errorf("Expecting QueryHooks to return a copy")
+	}
+
+	args := []interface{}{"secret"}
+	expectedErr := errors.New("failed")
+	_, done = StartQuery(context.Background(), OperationExec, "UPDATE a SET b = $1", args)
+	done(2, expectedErr)
+
+	if len(events) != 1 {
+		t.Fatalf("Expecting 1 event, got %d", len(events))
+	}
+	e := events[0]
+	if e.SQL != "UPDATE a SET b = $1" || e.Operation != OperationExec || e.RowsAffected != 2 || e.Err != expectedErr {
+		t.Errorf("Unexpected event %#v", e)
+	}
+	if len(e.Args) != 1 || e.Args[0] != RedactedArg {
+		t.Errorf("Expecting arguments to be redacted, got %v", e.Args)
+	}
+	if args[0] != "secret" {
+		t.Errorf("Expecting original arguments to be unmodified")
+	}
+
+	// Only the metric is logged, as the query is not slow.
+	if len(l.logs) != 1 {
+		t.Fatalf("Expecting 1 log, got %d", len(l.logs))
+	}
+	m, ok := l.logs[0].(*logger.MetricEntry)
+	if !ok || m.Name != "query_duration" || m.Unit != "ms" {
+		t.Errorf("Unexpected metric %#v", l.logs[0])
+	}
+
+	ClearQueryHooks()
+	AddQueryHook(&SlowQueryHook{Logger: l})
+	_, done = StartQuery(context.Background(), OperationQuery, "SELECT 1", nil)
+	done(-1, nil)
+	if len(l.logs) != 2 {
+		t.Fatalf("Expecting slow query to be logged, got %d logs", len(l.logs))
+	}
+}
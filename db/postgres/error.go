@@ -1,13 +1,184 @@
 package pgutil
 
-import "github.com/lib/pq"
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/lib/pq"
+
+	dbutil "github.com/monstercat/golib/db"
+)
 
 const (
 	ErrCodeConflict   pq.ErrorCode = "23503"
 	ErrCodeIncomplete pq.ErrorCode = "22P02"
 	ErrCodeDuplicate  pq.ErrorCode = "23505"
+
+	ErrCodeNotNull       pq.ErrorCode = "23502"
+	ErrCodeCheck         pq.ErrorCode = "23514"
+	ErrCodeExclusion     pq.ErrorCode = "23P01"
+	ErrCodeLockTimeout   pq.ErrorCode = "55P03"
+	ErrCodeQueryCanceled pq.ErrorCode = "57014"
+
+	// ErrCodeSerializationFailure and ErrCodeDeadlockDetected are defined in dbutil.
+)
+
+var (
+	ErrUniqueViolation           = errors.New("unique violation")
+	ErrForeignKeyViolation       = errors.New("foreign key violation")
+	ErrNotNullViolation          = errors.New("not null violation")
+	ErrCheckViolation            = errors.New("check violation")
+	ErrExclusionViolation        = errors.New("exclusion violation")
+	ErrInvalidTextRepresentation = errors.New("invalid text representation")
+	ErrSerializationFailure      = errors.New("serialization failure")
+	ErrDeadlockDetected          = errors.New("deadlock detected")
+	ErrLockTimeout               = errors.New("lock timeout")
+	ErrQueryCanceled             = errors.New("query canceled")
 )
 
+// ErrorKinds maps postgres error codes to the sentinel error used as Error.Kind.
+var ErrorKinds = map[pq.ErrorCode]error{
+	ErrCodeDuplicate:                   ErrUniqueViolation,
+	ErrCodeConflict:                    ErrForeignKeyViolation,
+	ErrCodeNotNull:                     ErrNotNullViolation,
+	ErrCodeCheck:                       ErrCheckViolation,
+	ErrCodeExclusion:                   ErrExclusionViolation,
+	ErrCodeIncomplete:                  ErrInvalidTextRepresentation,
+	dbutil.ErrCodeSerializationFailure: ErrSerializationFailure,
+	dbutil.ErrCodeDeadlockDetected:     ErrDeadlockDetected,
+	ErrCodeLockTimeout:                 ErrLockTimeout,
+	ErrCodeQueryCanceled:               ErrQueryCanceled,
+}
+
+// ErrorStatus maps an error (matched through errors.Is) to an HTTP status.
+type ErrorStatus struct {
+	Err    error
+	Status int
+}
+
+// HTTPStatuses are the statuses used by HTTPStatus. They are checked in order, so that the first match is used if an
+// error matches more than one.
+var HTTPStatuses = []ErrorStatus{
+	{sql.ErrNoRows, http.StatusNotFound},
+	{ErrUniqueViolation, http.StatusConflict},
+	{ErrExclusionViolation, http.StatusConflict},
+	{ErrForeignKeyViolation, http.StatusConflict},
+	{ErrNotNullViolation, http.StatusBadRequest},
+	{ErrCheckViolation, http.StatusBadRequest},
+	{ErrInvalidTextRepresentation, http.StatusBadRequest},
+	{ErrSerializationFailure, http.StatusServiceUnavailable},
+	{ErrDeadlockDetected, http.StatusServiceUnavailable},
+	{ErrLockTimeout, http.StatusServiceUnavailable},
+	{ErrQueryCanceled, http.StatusGatewayTimeout},
+}
+
+// Error is a classified postgres error. errors.Is matches Kind, and errors.As is able to retrieve the underlying
+// *pq.Error, as well as any error which wrapped it when it was classified.
+type Error struct {
+	// Kind is the sentinel error for the code (e.g., ErrUniqueViolation). It is nil if the code is not classified.
+	Kind error
+
+	Code       pq.ErrorCode
+	Table      string
+	Column     string
+	Constraint string
+	Detail     string
+
+	// Err is the original error.
+	Err *pq.Error
+
+	// Wrapped is the error passed to Classify if it wrapped Err with additional context (e.g., through fmt.Errorf).
+	Wrapped error
+}
+
+// Error string. If the error was wrapped, the context of the wrapping error is kept.
+func (e *Error) Error() string {
+	kind := string(e.Code)
+	if e.Kind != nil {
+		kind = e.Kind.Error()
+	}
+	if e.Constraint != "" {
+		kind += " on " + e.Constraint
+	}
+	msg := kind
+	if e.Err != nil {
+		msg += ": " + e.Err.Message
+	}
+
+	if e.Wrapped == nil {
+		return msg
+	}
+	wrapped := e.Wrapped.Error()
+	if e.Err != nil {
+		// e.g., "insert release: pq: duplicate key..." becomes "insert release: unique violation: duplicate key..."
+		if prefix := strings.TrimSuffix(wrapped, e.Err.Error()); prefix != wrapped {
+			return prefix + msg
+		}
+	}
+	return fmt.Sprintf("%s: %s", msg, wrapped)
+}
+
+// Is returns true if the target is Kind.
+func (e *Error) Is(target error) bool {
+	return e.Kind != nil && e.Kind == target
+}
+
+// Unwrap returns the wrapping error if there is one, otherwise the original error.
+func (e *Error) Unwrap() error {
+	if e.Wrapped != nil {
+		return e.Wrapped
+	}
+	if e.Err == nil {
+		return nil
+	}
+	return e.Err
+}
+
+// Classify converts the error into an *Error if it is (or wraps) a *pq.Error. If the error wraps the *pq.Error, it is
+// kept as Error.Wrapped. Other errors, including those which are already classified, are returned as is.
+func Classify(err error) error {
+	var cerr *Error
+	if errors.As(err, &cerr) {
+		return err
+	}
+	var perr *pq.Error
+	if !errors.As(err, &perr) {
+		return err
+	}
+	var wrapped error
+	if err != error(perr) {
+		wrapped = err
+	}
+	return &Error{
+		Kind:       ErrorKinds[perr.Code],
+		Code:       perr.Code,
+		Table:      perr.Table,
+		Column:     perr.Column,
+		Constraint: perr.Constraint,
+		Detail:     perr.Detail,
+		Err:        perr,
+		Wrapped:    wrapped,
+	}
+}
+
+// HTTPStatus returns the HTTP status for the error through HTTPStatuses. Postgres errors do not need to be classified
+// first. A nil error returns http.StatusOK and unknown errors return http.StatusInternalServerError.
+func HTTPStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	err = Classify(err)
+	for _, s := range HTTPStatuses {
+		if errors.Is(err, s.Err) {
+			return s.Status
+		}
+	}
+	return http.StatusInternalServerError
+}
+
 type ErrorConfig interface {
 	Test(e *pq.Error) bool
 }
@@ -30,22 +201,23 @@ func (c MatchesCode) Test(e *pq.Error) bool {
 	return e.Code == pq.ErrorCode(c)
 }
 
-// Transforms the error coming in *if* it is a PG error, based on the
-// configuration.
+// Transforms the error coming in *if* it is (or wraps) a PG error, based on
+// the configuration.
 //
 // Usage:
-//  cfg := map[ErrorConfig]error{
-//     MatchesConstraint("id_fkey"): ErrInvalidId,
-//  }
-//  ...
-//   _, err := db.Exec(....)
-//   return TransformError(err, cfg)
+//
+//	cfg := map[ErrorConfig]error{
+//	   MatchesConstraint("id_fkey"): ErrInvalidId,
+//	}
+//	...
+//	 _, err := db.Exec(....)
+//	 return TransformError(err, cfg)
 func TransformError(err error, cfg map[ErrorConfig]error) error {
 	if err == nil {
 		return nil
 	}
-	perr, ok := err.(*pq.Error)
-	if !ok {
+	var perr *pq.Error
+	if !errors.As(err, &perr) {
 		return err
 	}
 	for c, err := range cfg {
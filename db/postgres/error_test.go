@@ -3,6 +3,8 @@ package pgutil
 import (
 	"database/sql"
 	"errors"
+	"fmt"
+	"net/http"
 	"testing"
 
 	"github.com/lib/pq"
@@ -46,3 +48,119 @@ func TestTransformError(t *testing.T) {
 		}
 	}
 }
+
+func TestClassify(t *testing.T) {
+	perr := &pq.Error{
+		Code:       ErrCodeDuplicate,
+		Message:    "duplicate key value violates unique constraint",
+		Table:      "release",
+		Constraint: "release_catalog_id_key",
+		Detail:     "Key (catalog_id)=(MC001) already exists.",
+	}
+	err := Classify(fmt.Errorf("insert release: %w", perr))
+
+	if !errors.Is(err, ErrUniqueViolation) {
+		t.Errorf("Expecting unique violation, got %s", err)
+	}
+	if errors.Is(err, ErrForeignKeyViolation) {
+		t.Errorf("Not expecting foreign key violation")
+	}
+	var cerr *Error
+	if !errors.As(err, &cerr) {
+		t.Fatalf("Expecting *Error, got %T", err)
+	}
+	if cerr.Constraint != perr.Constraint || cerr.Table != perr.Table || cerr.Detail != perr.Detail {
+		t.Errorf("Fields were not extracted: %#v", cerr)
+	}
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr != perr {
+		t.Errorf("Expecting the original *pq.Error to be available")
+	}
+	expected := "insert release: unique violation on release_catalog_id_key: " +
+		"duplicate key value violates unique constraint"
+	if err.Error() != expected {
+		t.Errorf("Expected %s, got %s", expected, err)
+	}
+
+	// The wrapping context is kept.
+	err = Classify(contextError{perr})
+	var ctxErr contextError
+	if !errors.As(err, &ctxErr) || !errors.Is(err, ErrUniqueViolation) {
+		t.Errorf("Expecting both the wrapping error and unique violation, got %s", err)
+	}
+	if Classify(err) != err {
+		t.Error("Expecting classified errors to be returned as is")
+	}
+
+	err = Classify(perr)
+	expected = "unique violation on release_catalog_id_key: duplicate key value violates unique constraint"
+	if err.Error() != expected {
+		t.Errorf("Expected %s, got %s", expected, err)
+	}
+
+	// Errors without the original error can be printed.
+	err = &Error{Kind: ErrCheckViolation}
+	if err.Error() != "check violation" {
+		t.Errorf("Expected check violation, got %s", err)
+	}
+	if errors.Unwrap(err) != nil {
+		t.Error("Expecting nil from Unwrap")
+	}
+
+	if Classify(sql.ErrNoRows) != sql.ErrNoRows {
+		t.Error("Expecting non-pq errors to be returned as is")
+	}
+}
+
+// contextError wraps an error.
+type contextError struct {
+	err error
+}
+
+func (e contextError) Error() string {
+	return "context: " + e.err.Error()
+}
+
+func (e contextError) Unwrap() error {
+	return e.err
+}
+
+// multiError matches all its errors through errors.Is.
+type multiError []error
+
+func (e multiError) Error() string {
+	return "multi"
+}
+
+func (e multiError) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestHTTPStatus(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{err: nil, status: http.StatusOK},
+		{err: sql.ErrNoRows, status: http.StatusNotFound},
+		{err: &pq.Error{Code: ErrCodeDuplicate}, status: http.StatusConflict},
+		{err: &pq.Error{Code: ErrCodeNotNull}, status: http.StatusBadRequest},
+		{err: fmt.Errorf("wrapped: %w", &pq.Error{Code: "40001"}), status: http.StatusServiceUnavailable},
+		{err: &pq.Error{Code: ErrCodeQueryCanceled}, status: http.StatusGatewayTimeout},
+		{err: &pq.Error{Code: "XX000"}, status: http.StatusInternalServerError},
+		{err: errors.New("other"), status: http.StatusInternalServerError},
+
+		// The first match in HTTPStatuses is used.
+		{err: multiError{ErrQueryCanceled, sql.ErrNoRows}, status: http.StatusNotFound},
+	}
+	for i, test := range tests {
+		if status := HTTPStatus(test.err); status != test.status {
+			t.Errorf("[%d] Expected %d, got %d", i, test.status, status)
+		}
+	}
+}
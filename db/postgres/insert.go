@@ -1,6 +1,8 @@
 package pgutil
 
 import (
+	"context"
+
 	"github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
 
@@ -10,8 +12,7 @@ import (
 var Psql = squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 
 func Delete(db sqlx.Ext, qry squirrel.DeleteBuilder) error {
-	_, err := qry.RunWith(db).Exec()
-	return err
+	return exec(db, qry)
 }
 
 func DeleteWhere(db sqlx.Ext, table string, where interface{}) error {
@@ -19,8 +20,7 @@ func DeleteWhere(db sqlx.Ext, table string, where interface{}) error {
 }
 
 func Update(db sqlx.Ext, qry squirrel.UpdateBuilder) error {
-	_, err := qry.RunWith(db).Exec()
-	return err
+	return exec(db, qry)
 }
 
 func UpdateSetMap(db sqlx.Ext, table string, payload, where interface{}) error {
@@ -28,7 +28,14 @@ func UpdateSetMap(db sqlx.Ext, table string, payload, where interface{}) error {
 }
 
 func InsertReturningId(db sqlx.Ext, qry squirrel.InsertBuilder, id interface{}) error {
-	return qry.Suffix("RETURNING id").RunWith(db).Scan(id)
+	sql, args, err := qry.Suffix("RETURNING id").ToSql()
+	if err != nil {
+		return err
+	}
+	_, done := StartQuery(context.Background(), OperationQueryRow, sql, args)
+	err = db.QueryRowx(sql, args...).Scan(id)
+	done(-1, err)
+	return err
 }
 
 func InsertSetMapReturningId(db sqlx.Ext, table string, payload interface{}, id interface{}) error {
@@ -36,6 +43,17 @@ func InsertSetMapReturningId(db sqlx.Ext, table string, payload interface{}, id
 }
 
 func InsertSetMapNoId(db sqlx.Ext, table string, payload interface{}) error {
-	_, err := Psql.Insert(table).SetMap(SetMap(payload, true)).RunWith(db).Exec()
+	return exec(db, Psql.Insert(table).SetMap(SetMap(payload, true)))
+}
+
+// exec executes the query, notifying the query hooks.
+func exec(db sqlx.Ext, qry squirrel.Sqlizer) error {
+	sql, args, err := qry.ToSql()
+	if err != nil {
+		return err
+	}
+	_, done := StartQuery(context.Background(), OperationExec, sql, args)
+	res, err := db.Exec(sql, args...)
+	done(ResultRowsAffected(res), err)
 	return err
-}
\ No newline at end of file
+}
@@ -0,0 +1,93 @@
+package pgutil
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+
+	"github.com/monstercat/golib/operator"
+)
+
+// ArrayMode is the postgres array operator used by SearchOperatorConfigArray.
+type ArrayMode string
+
+const (
+	// ArrayContains requires the column to contain all the values.
+	ArrayContains ArrayMode = "@>"
+
+	// ArrayOverlap requires the column to contain any of the values.
+	ArrayOverlap ArrayMode = "&&"
+)
+
+func NewArrayOperator(field string, keys ...string) SearchOperatorConfigArray {
+	return SearchOperatorConfigArray{SearchOperatorConfigBase: NewSearchOperatorConfigBase(field, keys...)}
+}
+
+// SearchOperatorConfigArray filters array columns (e.g., text[]) by containment or overlap. Each value may be prefixed
+// by the ArrayMode to use and can contain multiple comma separated items, optionally surrounded by braces. For example,
+//
+//	tags:rock              tags @> {rock}
+//	tags:@>{rock,metal}    tags @> {rock,metal}
+//	tags:&&{rock,metal}    tags && {rock,metal}
+//	!tags:rock,metal       NOT (tags @> {rock,metal})
+//
+// If no ArrayMode is provided, Mode is used, which defaults to ArrayContains.
+type SearchOperatorConfigArray struct {
+	SearchOperatorConfigBase
+
+	// Mode is the default array operator.
+	Mode ArrayMode
+}
+
+func (c SearchOperatorConfigArray) mode() ArrayMode {
+	if c.Mode == "" {
+		return ArrayContains
+	}
+	return c.Mode
+}
+
+// parseArrayValue splits the value into its mode and items.
+func (c SearchOperatorConfigArray) parseArrayValue(v string) (ArrayMode, []string) {
+	mode := c.mode()
+	for _, m := range []ArrayMode{ArrayContains, ArrayOverlap} {
+		if strings.HasPrefix(v, string(m)) {
+			mode = m
+			v = v[len(m):]
+			break
+		}
+	}
+	v = strings.TrimSuffix(strings.TrimPrefix(v, "{"), "}")
+
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return mode, items
+}
+
+// Sqlizer returns the condition for the operator. Values are ANDed together.
+func (c SearchOperatorConfigArray) Sqlizer(o operator.Operator, prefix string) squirrel.Sqlizer {
+	and := squirrel.And{}
+	for _, v := range o.Values {
+		mode, items := c.parseArrayValue(v)
+		if len(items) == 0 {
+			continue
+		}
+		var sql squirrel.Sqlizer = squirrel.Expr(fmt.Sprintf("%s%s %s ?", prefix, c.Field, mode), pq.StringArray(items))
+		if o.Has(operator.ModifierNot) {
+			sql = Not{sql}
+		}
+		and = append(and, sql)
+	}
+	return simplifyConjunction(and)
+}
+
+func (c SearchOperatorConfigArray) Apply(os, rem []operator.Operator, a *Accumulator, prefix string) {
+	loopOperators(os, a, func(o operator.Operator) squirrel.Sqlizer {
+		return c.Sqlizer(o, prefix)
+	})
+}
@@ -0,0 +1,113 @@
+package pgutil
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+
+	"github.com/monstercat/golib/operator"
+)
+
+func TestSearchOperatorConfigArray(t *testing.T) {
+	p, err := operator.NewParser(&operator.ParserConfig{
+		StringStart:  "\"",
+		StringEnd:    "\"",
+		KeyDelimiter: ":",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	overlap := NewArrayOperator("genres", "genre")
+	overlap.Mode = ArrayOverlap
+
+	config := []ISearchOperatorConfig{
+		NewArrayOperator("tags", "tags"),
+		overlap,
+	}
+
+	tests := []struct {
+		s    string
+		sql  string
+		args []interface{}
+	}{
+		{
+			s:    "tags:rock",
+			sql:  "((t.tags @> $1))",
+			args: []interface{}{pq.StringArray{"rock"}},
+		},
+		{
+			s:    "tags:&&{rock,metal} !tags:@>pop",
+			sql:  "((t.tags && $1 AND NOT (t.tags @> $2)))",
+			args: []interface{}{pq.StringArray{"rock", "metal"}, pq.StringArray{"pop"}},
+		},
+		{
+			s:    `!tags:"{ rock, , metal }"`,
+			sql:  "((NOT (t.tags @> $1)))",
+			args: []interface{}{pq.StringArray{"rock", "metal"}},
+		},
+		{
+			s:    "genre:rock,metal genre:@>pop",
+			sql:  "((t.genres && $1 AND t.genres @> $2))",
+			args: []interface{}{pq.StringArray{"rock", "metal"}, pq.StringArray{"pop"}},
+		},
+		{
+			// Values without any items are ignored.
+			s:    "tags:{} tags:&&",
+			sql:  "",
+			args: nil,
+		},
+	}
+
+	for i, test := range tests {
+		qry := squirrel.Select("*").From("t")
+		ApplyOperators(&qry, config, p.Parse(test.s), "t.")
+
+		sql, args, err := qry.PlaceholderFormat(squirrel.Dollar).ToSql()
+		if err != nil {
+			t.Fatalf("[%d] %s", i, err)
+		}
+		expected := "SELECT * FROM t"
+		if test.sql != "" {
+			expected += " WHERE " + test.sql
+		}
+		if sql != expected {
+			t.Errorf("[%d] Expected sql %s, got %s", i, expected, sql)
+		}
+		if !reflect.DeepEqual(args, test.args) {
+			t.Errorf("[%d] Expected args %v, got %v", i, test.args, args)
+		}
+	}
+}
+
+func TestSearchOperatorConfigArray_Sqlizer(t *testing.T) {
+	c := NewArrayOperator("tags", "tags")
+
+	sql, args, err := c.Sqlizer(operator.Operator{
+		Values: []string{"rock", "&&{metal,pop}"},
+	}, "t.").ToSql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql != "(t.tags @> ? AND t.tags && ?)" {
+		t.Errorf("Unexpected sql %s", sql)
+	}
+	expectedArgs := []interface{}{pq.StringArray{"rock"}, pq.StringArray{"metal", "pop"}}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("Expected args %v, got %v", expectedArgs, args)
+	}
+
+	// A single value is not wrapped.
+	sql, _, err = c.Sqlizer(operator.Operator{
+		Values:    []string{"rock"},
+		Modifiers: []operator.Modifier{operator.ModifierNot},
+	}, "").ToSql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql != "NOT (tags @> ?)" {
+		t.Errorf("Unexpected sql %s", sql)
+	}
+}
@@ -0,0 +1,155 @@
+package pgutil
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/shopspring/decimal"
+
+	"github.com/monstercat/golib/operator"
+)
+
+// DefaultDateLayouts are the layouts used by SearchOperatorConfigDate if none are provided.
+var DefaultDateLayouts = []string{
+	"2006-01-02",
+	time.RFC3339,
+}
+
+func NewNumberOperator(field string, keys ...string) SearchOperatorConfigNumber {
+	return SearchOperatorConfigNumber{NewSearchOperatorConfigBase(field, keys...)}
+}
+
+func NewDecimalOperator(field string, keys ...string) SearchOperatorConfigDecimal {
+	return SearchOperatorConfigDecimal{NewSearchOperatorConfigBase(field, keys...)}
+}
+
+func NewDateOperator(field string, keys ...string) SearchOperatorConfigDate {
+	return SearchOperatorConfigDate{SearchOperatorConfigBase: NewSearchOperatorConfigBase(field, keys...)}
+}
+
+// comparisonParser converts a string value into a value which can be passed as an argument to postgres.
+type comparisonParser func(v string) (interface{}, error)
+
+// comparisonSqlizer generates the SQL for the operator based on its comparator. Values which cannot be parsed are
+// ignored. Returns nil if no SQL can be generated.
+//
+//	ComparatorNone, ComparatorEq   field = ? [OR field = ?...]
+//	ComparatorGt, ...              field > ?
+//	ComparatorRange                field BETWEEN ? AND ?, field >= ?, field <= ?
+func comparisonSqlizer(field string, o operator.Operator, parse comparisonParser) squirrel.Sqlizer {
+	var sql squirrel.Sqlizer
+	switch o.Comparator {
+	case operator.ComparatorNone, operator.ComparatorEq:
+		or := squirrel.Or{}
+		for _, v := range o.Values {
+			val, err := parse(v)
+			if err != nil {
+				continue
+			}
+			or = append(or, squirrel.Expr(field+" = ?", val))
+		}
+		sql = simplifyConjunction(or)
+	case operator.ComparatorGt, operator.ComparatorGte, operator.ComparatorLt, operator.ComparatorLte:
+		if len(o.Values) == 0 {
+			return nil
+		}
+		val, err := parse(o.Values[0])
+		if err != nil {
+			return nil
+		}
+		sql = squirrel.Expr(fmt.Sprintf("%s %s ?", field, o.Comparator), val)
+	case operator.ComparatorRange:
+		if len(o.Values) != 2 {
+			return nil
+		}
+		from, fromErr := parseOptional(o.Values[0], parse)
+		to, toErr := parseOptional(o.Values[1], parse)
+		if fromErr != nil || toErr != nil {
+			return nil
+		}
+		switch {
+		case from != nil && to != nil:
+			sql = squirrel.Expr(field+" BETWEEN ? AND ?", from, to)
+		case from != nil:
+			sql = squirrel.Expr(field+" >= ?", from)
+		case to != nil:
+			sql = squirrel.Expr(field+" <= ?", to)
+		}
+	}
+	if sql == nil {
+		return nil
+	}
+	if o.Has(operator.ModifierNot) {
+		return Not{sql}
+	}
+	return sql
+}
+
+// parseOptional parses the value, returning nil if it is empty.
+func parseOptional(v string, parse comparisonParser) (interface{}, error) {
+	if v == "" {
+		return nil, nil
+	}
+	return parse(v)
+}
+
+// SearchOperatorConfigNumber compares integer columns. Supports all operator.Comparator values.
+type SearchOperatorConfigNumber struct {
+	SearchOperatorConfigBase
+}
+
+func (c SearchOperatorConfigNumber) Apply(os, rem []operator.Operator, a *Accumulator, prefix string) {
+	loopOperators(os, a, func(o operator.Operator) squirrel.Sqlizer {
+		return comparisonSqlizer(prefix+c.Field, o, func(v string) (interface{}, error) {
+			return strconv.ParseInt(v, 10, 64)
+		})
+	})
+}
+
+// SearchOperatorConfigDecimal compares numeric columns with arbitrary precision. Supports all operator.Comparator
+// values.
+type SearchOperatorConfigDecimal struct {
+	SearchOperatorConfigBase
+}
+
+func (c SearchOperatorConfigDecimal) Apply(os, rem []operator.Operator, a *Accumulator, prefix string) {
+	loopOperators(os, a, func(o operator.Operator) squirrel.Sqlizer {
+		return comparisonSqlizer(prefix+c.Field, o, func(v string) (interface{}, error) {
+			return decimal.NewFromString(v)
+		})
+	})
+}
+
+// SearchOperatorConfigDate compares date or timestamp columns. Supports all operator.Comparator values. Values are
+// parsed using Layouts, in order, defaulting to DefaultDateLayouts.
+//
+// To compare timestamp columns by day, cast the field, e.g., NewDateOperator("created::date", "created").
+type SearchOperatorConfigDate struct {
+	SearchOperatorConfigBase
+
+	// Layouts used to parse the values through time.Parse.
+	Layouts []string
+}
+
+func (c SearchOperatorConfigDate) parse(v string) (interface{}, error) {
+	layouts := c.Layouts
+	if len(layouts) == 0 {
+		layouts = DefaultDateLayouts
+	}
+	var err error
+	for _, l := range layouts {
+		var t time.Time
+		if t, err = time.Parse(l, v); err == nil {
+			return t, nil
+		}
+	}
+	return nil, err
+}
+
+func (c SearchOperatorConfigDate) Apply(os, rem []operator.Operator, a *Accumulator, prefix string) {
+	loopOperators(os, a, func(o operator.Operator) squirrel.Sqlizer {
+		return comparisonSqlizer(prefix+c.Field, o, c.parse)
+	})
+}
@@ -0,0 +1,84 @@
+package pgutil
+
+import (
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+
+	"github.com/monstercat/golib/operator"
+)
+
+func TestSearchOperatorConfigComparison(t *testing.T) {
+	p, err := operator.NewParser(&operator.ParserConfig{
+		StringStart:    "\"",
+		StringEnd:      "\"",
+		KeyDelimiter:   ":",
+		ComparatorKeys: []string{"plays", "released", "price"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := []ISearchOperatorConfig{
+		NewNumberOperator("plays", "plays"),
+		NewDateOperator("released::date", "released"),
+		NewDecimalOperator("price", "price"),
+	}
+
+	tests := []struct {
+		s    string
+		sql  string
+		args int
+	}{
+		{
+			s:    "plays:>1000",
+			sql:  "((t.plays > ?))",
+			args: 1,
+		},
+		{
+			s:    "plays:abc",
+			sql:  "",
+			args: 0,
+		},
+		{
+			s:    "released:2023-01-01..2023-06-30",
+			sql:  "((t.released::date BETWEEN ? AND ?))",
+			args: 2,
+		},
+		{
+			s:    "!released:..2023-06-30",
+			sql:  "((NOT (t.released::date <= ?)))",
+			args: 1,
+		},
+		{
+			s:    "price:<=9.99",
+			sql:  "((t.price <= ?))",
+			args: 1,
+		},
+		{
+			s:    "price:9.99",
+			sql:  "((t.price = ?))",
+			args: 1,
+		},
+	}
+
+	for i, test := range tests {
+		qry := squirrel.Select("*").From("t")
+		ApplyOperators(&qry, config, p.Parse(test.s), "t.")
+
+		sql, args, err := qry.ToSql()
+		if err != nil {
+			t.Fatalf("[%d] %s", i, err)
+		}
+		expected := "SELECT * FROM t"
+		if test.sql != "" {
+			expected += " WHERE " + test.sql
+		}
+		if sql != expected {
+			t.Errorf("[%d] Expected sql %s, got %s", i, expected, sql)
+		}
+		if len(args) != test.args {
+			t.Errorf("[%d] Expected %d args, got %d", i, test.args, len(args))
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package pgutil
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+
+	"github.com/monstercat/golib/operator"
+)
+
+// InvalidValueError is reported through Accumulator.AddError when an operator value is not one of the allowed values.
+// It unwraps to operator.ErrSchemaInvalidValue.
+type InvalidValueError struct {
+	// Keys of the config which rejected the value.
+	Keys []string
+
+	// Value that was rejected.
+	Value string
+
+	// Allowed values.
+	Allowed []string
+
+	// Suggestion is the closest allowed value, if any.
+	Suggestion string
+}
+
+// Error string
+func (e *InvalidValueError) Error() string {
+	str := fmt.Sprintf("Value '%s' for key '%s' has been ignored. Expecting one of: %s.",
+		e.Value, strings.Join(e.Keys, "/"), strings.Join(e.Allowed, ", "))
+	if e.Suggestion != "" {
+		str += fmt.Sprintf(" Did you mean '%s'?", e.Suggestion)
+	}
+	return str
+}
+
+// Unwrap returns operator.ErrSchemaInvalidValue
+func (e *InvalidValueError) Unwrap() error {
+	return operator.ErrSchemaInvalidValue
+}
+
+func newInvalidValueError(keys []string, value string, allowed []string) *InvalidValueError {
+	return &InvalidValueError{
+		Keys:       keys,
+		Value:      value,
+		Allowed:    allowed,
+		Suggestion: operator.Suggest(value, allowed),
+	}
+}
+
+// splitValues splits each of the values by comma.
+func splitValues(values []string) []string {
+	var xs []string
+	for _, v := range values {
+		for _, x := range strings.Split(v, ",") {
+			if x = strings.TrimSpace(x); x != "" {
+				xs = append(xs, x)
+			}
+		}
+	}
+	return xs
+}
+
+func NewEnumOperator(field string, allowed []string, keys ...string) SearchOperatorConfigEnum {
+	return SearchOperatorConfigEnum{
+		SearchOperatorConfigBase: NewSearchOperatorConfigBase(field, keys...),
+		Allowed:                  allowed,
+	}
+}
+
+// SearchOperatorConfigEnum matches the field against a set of values, which must be in Allowed. Values are matched
+// case-insensitively and may be comma separated, e.g., status:draft,published.
+//
+// Values which are not allowed are ignored and reported through Accumulator.AddError. If none of the values of an
+// operator are allowed, the operator is ignored.
+type SearchOperatorConfigEnum struct {
+	SearchOperatorConfigBase
+
+	// Allowed values.
+	Allowed []string
+}
+
+// normalize returns the allowed value matching v.
+func (c SearchOperatorConfigEnum) normalize(v string) (string, bool) {
+	for _, a := range c.Allowed {
+		if strings.EqualFold(a, v) {
+			return a, true
+		}
+	}
+	return "", false
+}
+
+func (c SearchOperatorConfigEnum) Apply(os, rem []operator.Operator, a *Accumulator, prefix string) {
+	loopOperators(os, a, func(o operator.Operator) squirrel.Sqlizer {
+		var values pq.StringArray
+		for _, v := range splitValues(o.Values) {
+			n, ok := c.normalize(v)
+			if !ok {
+				a.AddError(newInvalidValueError(c.Keys, v, c.Allowed))
+				continue
+			}
+			values = append(values, n)
+		}
+		if len(values) == 0 {
+			return nil
+		}
+		var sql squirrel.Sqlizer = squirrel.Expr(fmt.Sprintf("%s%s = ANY(?)", prefix, c.Field), values)
+		if o.Has(operator.ModifierNot) {
+			sql = Not{sql}
+		}
+		return sql
+	})
+}
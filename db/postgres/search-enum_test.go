@@ -0,0 +1,68 @@
+package pgutil
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+
+	"github.com/monstercat/golib/operator"
+)
+
+func TestSearchOperatorConfigEnumAndExists(t *testing.T) {
+	p, err := operator.NewParser(&operator.ParserConfig{
+		StringStart:  "\"",
+		StringEnd:    "\"",
+		KeyDelimiter: ":",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	columns := map[string]string{
+		"isrc": "isrc",
+		"upc":  "upc",
+	}
+	config := []ISearchOperatorConfig{
+		NewEnumOperator("status", []string{"draft", "published"}, "status"),
+		NewExistsOperator(columns, "has"),
+		NewMissingOperator(columns, "missing"),
+	}
+
+	ops := p.Parse("status:Draft,publishd has:isrc !missing:upc missing:isbn")
+	qry := squirrel.Select("*").From("t")
+	ApplyOperatorsWithErrors(&qry, config, &ops, "t.")
+
+	sql, args, err := qry.ToSql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedSql := "SELECT * FROM t WHERE ((t.status = ANY(?) AND t.isrc IS NOT NULL AND t.upc IS NOT NULL))"
+	if sql != expectedSql {
+		t.Errorf("Expected sql %s, got %s", expectedSql, sql)
+	}
+	expectedArgs := []interface{}{pq.StringArray{"draft"}}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("Expected args %v, got %v", expectedArgs, args)
+	}
+
+	if len(ops.Errors) != 2 {
+		t.Fatalf("Expecting 2 errors, got %v", ops.Errors)
+	}
+	for _, err := range ops.Errors {
+		if !errors.Is(err, operator.ErrSchemaInvalidValue) {
+			t.Errorf("Expecting invalid value error, got %s", err)
+		}
+	}
+	expectedErrs := []string{
+		"Value 'publishd' for key 'status' has been ignored. Expecting one of: draft, published. Did you mean 'published'?",
+		"Value 'isbn' for key 'missing' has been ignored. Expecting one of: isrc, upc.",
+	}
+	for i, err := range ops.Errors {
+		if err.Error() != expectedErrs[i] {
+			t.Errorf("[%d] Expected error %s, got %s", i, expectedErrs[i], err)
+		}
+	}
+}
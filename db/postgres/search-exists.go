@@ -0,0 +1,75 @@
+package pgutil
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Masterminds/squirrel"
+
+	"github.com/monstercat/golib/operator"
+)
+
+// NewExistsOperator creates a config for filters such as has:isrc, which require the column to be NOT NULL. Columns
+// maps the values to the column names.
+func NewExistsOperator(columns map[string]string, keys ...string) SearchOperatorConfigExists {
+	return SearchOperatorConfigExists{
+		SearchOperatorConfigBase: SearchOperatorConfigBase{Keys: keys},
+		Columns:                  columns,
+	}
+}
+
+// NewMissingOperator creates a config for filters such as missing:upc, which require the column to be NULL. Columns
+// maps the values to the column names.
+func NewMissingOperator(columns map[string]string, keys ...string) SearchOperatorConfigExists {
+	c := NewExistsOperator(columns, keys...)
+	c.Missing = true
+	return c
+}
+
+// SearchOperatorConfigExists checks for the existence (IS NOT NULL) or absence (IS NULL) of columns. The operator
+// values select the column through the Columns map, and may be comma separated, e.g., has:isrc,upc. The NOT modifier
+// inverts the check. The Field of the base config is not used.
+//
+// Values which are not in Columns are ignored and reported through Accumulator.AddError.
+type SearchOperatorConfigExists struct {
+	SearchOperatorConfigBase
+
+	// Columns maps the operator value to the column to check.
+	Columns map[string]string
+
+	// Missing checks for IS NULL instead of IS NOT NULL.
+	Missing bool
+}
+
+func (c SearchOperatorConfigExists) allowed() []string {
+	xs := make([]string, 0, len(c.Columns))
+	for k := range c.Columns {
+		xs = append(xs, k)
+	}
+	sort.Strings(xs)
+	return xs
+}
+
+func (c SearchOperatorConfigExists) Apply(os, rem []operator.Operator, a *Accumulator, prefix string) {
+	loopOperators(os, a, func(o operator.Operator) squirrel.Sqlizer {
+		missing := c.Missing
+		if o.Has(operator.ModifierNot) {
+			missing = !missing
+		}
+		check := "IS NOT NULL"
+		if missing {
+			check = "IS NULL"
+		}
+
+		and := squirrel.And{}
+		for _, v := range splitValues(o.Values) {
+			col, ok := c.Columns[v]
+			if !ok {
+				a.AddError(newInvalidValueError(c.Keys, v, c.allowed()))
+				continue
+			}
+			and = append(and, squirrel.Expr(fmt.Sprintf("%s%s %s", prefix, col, check)))
+		}
+		return simplifyConjunction(and)
+	})
+}
@@ -0,0 +1,198 @@
+package pgutil
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+
+	golibErrors "github.com/monstercat/golib/errors"
+	"github.com/monstercat/golib/operator"
+)
+
+// Not negates the provided sqlizer.
+type Not struct {
+	squirrel.Sqlizer
+}
+
+func (n Not) ToSql() (string, []interface{}, error) {
+	sql, args, err := n.Sqlizer.ToSql()
+	if err != nil {
+		return "", nil, err
+	}
+	return "NOT (" + sql + ")", args, nil
+}
+
+// ErrExpressionUnhandled is returned when a leaf of an expression is not handled by any ISearchOperatorConfig.
+// Ignoring the leaf would widen the results under AND and narrow them under OR.
+var ErrExpressionUnhandled = errors.New("operator is not handled by any search config")
+
+// ExpressionLeafError is an error related to a single leaf of the expression.
+type ExpressionLeafError struct {
+	// The error in question. Either ErrExpressionUnhandled or an error reported by a config through
+	// Accumulator.AddError.
+	Base error
+
+	// Key of the leaf. Empty for remainders.
+	Key string
+
+	// Position of the leaf in the input string, as set by the parser.
+	Position int
+}
+
+// Error string
+func (e *ExpressionLeafError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("Remainder at position %d could not be applied. %s", e.Position, e.Base)
+	}
+	return fmt.Sprintf("Key '%s' at position %d could not be applied. %s", e.Key, e.Position, e.Base)
+}
+
+// Unwrap returns the base error.
+func (e *ExpressionLeafError) Unwrap() error {
+	return e.Base
+}
+
+// ApplyExpression applies the provided expression tree to the query. Unlike ApplyOperators, the layout of the
+// resultant condition follows the structure of the expression, rather than the fixed layout of the Accumulator.
+//
+// If an error is returned, the query is not modified. See ExpressionCondition.
+func ApplyExpression(
+	query *squirrel.SelectBuilder,
+	config []ISearchOperatorConfig,
+	expr *operator.Expression,
+	prefix string,
+) error {
+	sqlizer, err := ExpressionCondition(config, expr, prefix)
+	if err != nil {
+		return err
+	}
+	if sqlizer != nil {
+		*query = query.Where(sqlizer)
+	}
+	return nil
+}
+
+// ExpressionCondition converts the provided expression tree into a nested squirrel condition. Each leaf is passed
+// through the ISearchOperatorConfig which handles its key, while remainders are passed through every config.
+//
+// Unlike ApplyOperators, leaves which do not produce a condition are not ignored, as doing so would change the meaning
+// of the expression. An ExpressionLeafError wrapping ErrExpressionUnhandled is returned for each of them, along with
+// one for each error reported by the configs. The errors are returned as an errors.Errors.
+//
+// Returns nil if no conditions are produced.
+func ExpressionCondition(
+	config []ISearchOperatorConfig,
+	expr *operator.Expression,
+	prefix string,
+) (squirrel.Sqlizer, error) {
+	if expr == nil {
+		return nil, nil
+	}
+	keyed := make(map[string][]ISearchOperatorConfig)
+	for _, c := range config {
+		for _, k := range c.GetKeys() {
+			keyed[k] = append(keyed[k], c)
+		}
+	}
+	var errs golibErrors.Errors
+	sqlizer := expressionCondition(config, keyed, expr, prefix, &errs)
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	return sqlizer, nil
+}
+
+func expressionCondition(
+	config []ISearchOperatorConfig,
+	keyed map[string][]ISearchOperatorConfig,
+	expr *operator.Expression,
+	prefix string,
+	errs *golibErrors.Errors,
+) squirrel.Sqlizer {
+	switch expr.Type {
+	case operator.ExpressionOperator:
+		return leafCondition(config, keyed, expr, prefix, errs)
+	case operator.ExpressionNot:
+		if len(expr.Children) == 0 {
+			return nil
+		}
+		child := expressionCondition(config, keyed, expr.Children[0], prefix, errs)
+		if child == nil {
+			return nil
+		}
+		return Not{child}
+	case operator.ExpressionAnd:
+		and := squirrel.And{}
+		for _, c := range expr.Children {
+			if s := expressionCondition(config, keyed, c, prefix, errs); s != nil {
+				and = append(and, s)
+			}
+		}
+		return simplifyConjunction(and)
+	case operator.ExpressionOr:
+		or := squirrel.Or{}
+		for _, c := range expr.Children {
+			if s := expressionCondition(config, keyed, c, prefix, errs); s != nil {
+				or = append(or, s)
+			}
+		}
+		return simplifyConjunction(or)
+	}
+	return nil
+}
+
+// leafCondition runs the leaf operator through the applicable configs using a dedicated Accumulator. Errors are added
+// if the configs report any, or if no condition is produced.
+func leafCondition(
+	config []ISearchOperatorConfig,
+	keyed map[string][]ISearchOperatorConfig,
+	expr *operator.Expression,
+	prefix string,
+	errs *golibErrors.Errors,
+) squirrel.Sqlizer {
+	ops := []operator.Operator{expr.Operator}
+	a := &Accumulator{}
+	if expr.IsRemainder() {
+		for _, c := range config {
+			c.Apply(nil, ops, a, prefix)
+		}
+	} else {
+		for _, c := range keyed[expr.Key] {
+			c.Apply(ops, nil, a, prefix)
+		}
+	}
+
+	leafError := func(err error) {
+		errs.AddError(&ExpressionLeafError{
+			Base:     err,
+			Key:      expr.Key,
+			Position: expr.Position,
+		})
+	}
+	if len(a.Errors()) > 0 {
+		for _, err := range a.Errors() {
+			leafError(err)
+		}
+		return nil
+	}
+	cond := a.GetCondition()
+	if cond == nil {
+		leafError(ErrExpressionUnhandled)
+	}
+	return cond
+}
+
+// simplifyConjunction returns nil for empty conjunctions and the item itself for single item conjunctions.
+func simplifyConjunction[T interface {
+	~[]squirrel.Sqlizer
+	squirrel.Sqlizer
+}](xs T) squirrel.Sqlizer {
+	switch len(xs) {
+	case 0:
+		return nil
+	case 1:
+		return xs[0]
+	}
+	return xs
+}
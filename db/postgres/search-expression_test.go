@@ -0,0 +1,117 @@
+package pgutil
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+
+	"github.com/monstercat/golib/operator"
+)
+
+func TestExpressionCondition(t *testing.T) {
+	p, err := operator.NewParser(&operator.ExpressionParserConfig{
+		ParserConfig: operator.ParserConfig{
+			StringStart:  "\"",
+			StringEnd:    "\"",
+			KeyDelimiter: ":",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := []ISearchOperatorConfig{
+		NewUUIDOperator("id", "id"),
+		NewStringLikeOperator("title", "title"),
+		NewBoolOperator("public", "public"),
+	}
+
+	tests := []struct {
+		s    string
+		sql  string
+		args int
+	}{
+		{
+			s:    "",
+			sql:  "",
+			args: 0,
+		},
+		{
+			s:    "(id:1 | title:foo) !public:true",
+			sql:  "((((t.id = ANY(?))) OR (((t.title ILIKE ?)))) AND NOT (((t.public))))",
+			args: 2,
+		},
+		{
+			s:    "foo",
+			sql:  "(((t.title ILIKE ?)))",
+			args: 1,
+		},
+	}
+
+	for i, test := range tests {
+		expr, err := p.ParseExpression(test.s)
+		if err != nil {
+			t.Fatalf("[%d] %s", i, err)
+		}
+		qry := squirrel.Select("*").From("t")
+		if err := ApplyExpression(&qry, config, expr, "t."); err != nil {
+			t.Fatalf("[%d] %s", i, err)
+		}
+
+		sql, args, err := qry.ToSql()
+		if err != nil {
+			t.Fatalf("[%d] %s", i, err)
+		}
+		expected := "SELECT * FROM t"
+		if test.sql != "" {
+			expected += " WHERE " + test.sql
+		}
+		if sql != expected {
+			t.Errorf("[%d] Expected sql %s, got %s", i, expected, sql)
+		}
+		if len(args) != test.args {
+			t.Errorf("[%d] Expected %d args, got %d", i, test.args, len(args))
+		}
+	}
+}
+
+func TestExpressionConditionErrors(t *testing.T) {
+	p, err := operator.NewParser(&operator.ExpressionParserConfig{
+		ParserConfig: operator.ParserConfig{
+			KeyDelimiter: ":",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := []ISearchOperatorConfig{
+		NewStringLikeOperator("title", "title"),
+	}
+
+	// Ignoring the unknown leaf would turn the OR into title:foo.
+	expr, err := p.ParseExpression("unknown:1 | title:foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	qry := squirrel.Select("*").From("t")
+	err = ApplyExpression(&qry, config, expr, "t.")
+	if !errors.Is(err, ErrExpressionUnhandled) {
+		t.Fatalf("Expecting ErrExpressionUnhandled, got %v", err)
+	}
+	var lerr *ExpressionLeafError
+	if !errors.As(err, &lerr) {
+		t.Fatal("Expecting ExpressionLeafError")
+	}
+	if lerr.Key != "unknown" || lerr.Position != 0 {
+		t.Errorf("Expecting key 'unknown' at position 0, got '%s' at %d", lerr.Key, lerr.Position)
+	}
+	sql, _, err := qry.ToSql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql != "SELECT * FROM t" {
+		t.Errorf("Expecting query to be unmodified, got %s", sql)
+	}
+}
@@ -0,0 +1,152 @@
+package pgutil
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+
+	"github.com/monstercat/golib/operator"
+)
+
+// TsQueryFunc is the postgres function used to convert text into a tsquery.
+type TsQueryFunc string
+
+const (
+	// TsQueryWebsearch supports quoted phrases, OR and - (negation), similar to web search engines.
+	TsQueryWebsearch TsQueryFunc = "websearch_to_tsquery"
+
+	// TsQueryPlain ANDs all terms, ignoring punctuation.
+	TsQueryPlain TsQueryFunc = "plainto_tsquery"
+
+	// TsQueryPhrase requires all terms to be adjacent, in order.
+	TsQueryPhrase TsQueryFunc = "phraseto_tsquery"
+)
+
+func NewFullTextOperator(field, language string, keys ...string) SearchOperatorConfigFullText {
+	return SearchOperatorConfigFullText{
+		SearchOperatorConfigBase: NewSearchOperatorConfigBase(field, keys...),
+		Language:                 language,
+	}
+}
+
+// SearchOperatorConfigFullText matches operators and remainders against a tsvector column (Field) using a tsquery.
+// Unlike SearchOperatorConfigStringLike, it is able to use GIN / GiST indexes on the column.
+//
+// All remainders are combined into a single tsquery.
+type SearchOperatorConfigFullText struct {
+	SearchOperatorConfigBase
+
+	// Language is the text search configuration (e.g., english) used to generate the tsquery. If empty, the server's
+	// default_text_search_config is used. It should match the configuration used to generate the tsvector column.
+	Language string
+
+	// QueryFunc is the function used to generate the tsquery. Defaults to TsQueryWebsearch.
+	QueryFunc TsQueryFunc
+}
+
+func (c SearchOperatorConfigFullText) queryFunc() TsQueryFunc {
+	if c.QueryFunc == "" {
+		return TsQueryWebsearch
+	}
+	return c.QueryFunc
+}
+
+// TsQuery returns the tsquery sqlizer for the provided text.
+func (c SearchOperatorConfigFullText) TsQuery(text string) squirrel.Sqlizer {
+	if c.Language == "" {
+		return squirrel.Expr(fmt.Sprintf("%s(?)", c.queryFunc()), text)
+	}
+	return squirrel.Expr(fmt.Sprintf("%s(?::regconfig, ?)", c.queryFunc()), c.Language, text)
+}
+
+// Sqlizer returns the match condition for the provided text.
+func (c SearchOperatorConfigFullText) Sqlizer(text string, not bool, prefix string) squirrel.Sqlizer {
+	sql, args, _ := c.TsQuery(text).ToSql()
+	var n string
+	if not {
+		n = "NOT "
+	}
+	return squirrel.Expr(fmt.Sprintf("%s%s%s @@ %s", n, prefix, c.Field, sql), args...)
+}
+
+func (c SearchOperatorConfigFullText) Apply(os, rem []operator.Operator, a *Accumulator, prefix string) {
+	loopOperators(os, a, func(o operator.Operator) squirrel.Sqlizer {
+		text := strings.Join(o.Values, " ")
+		if strings.TrimSpace(text) == "" {
+			return nil
+		}
+		return c.Sqlizer(text, o.Has(operator.ModifierNot), prefix)
+	})
+	if text := joinOperatorValues(rem); text != "" {
+		a.ApplyRemainder(c.Sqlizer(text, false, prefix))
+	}
+}
+
+// RankSort returns a Sort which orders by ts_rank against the search text found in the operators (both the
+// operators matching the keys and the remainders). Operators with the NOT modifier are ignored.
+//
+// The returned Sort should be passed to ApplySort, e.g.,
+//
+//	ApplySort(sorts, &qry, map[string]Sort{
+//	    "relevance": c.RankSort(ops, "t."),
+//	})
+//
+// Use "-relevance" to return the most relevant results first.
+func (c SearchOperatorConfigFullText) RankSort(ops operator.Operators, prefix string) Sort {
+	sort := FullTextRankSort{Column: prefix + c.Field}
+	if text := searchText(ops, c.GetKeys()); text != "" {
+		sort.Query = c.TsQuery(text)
+	}
+	return sort
+}
+
+// FullTextRankSort orders by the ts_rank of Column against Query. It implements SqlizerSort.
+type FullTextRankSort struct {
+	// Column is the tsvector column, including any prefix.
+	Column string
+
+	// Query is the tsquery. If nil (e.g., there is no search text), the sort is ignored.
+	Query squirrel.Sqlizer
+}
+
+// String returns the order by statement with placeholders. As the query requires arguments, ApplySort uses Sqlizer
+// instead.
+func (s FullTextRankSort) String(key string) string {
+	sqlizer := s.Sqlizer(key)
+	if sqlizer == nil {
+		return ""
+	}
+	sql, _, _ := sqlizer.ToSql()
+	return sql
+}
+
+// Sqlizer returns the order by statement along with its arguments.
+func (s FullTextRankSort) Sqlizer(key string) squirrel.Sqlizer {
+	if s.Query == nil {
+		return nil
+	}
+	sql, args, err := s.Query.ToSql()
+	if err != nil {
+		return nil
+	}
+	return squirrel.Expr(fmt.Sprintf("ts_rank(%s, %s) %s", s.Column, sql, getDirection(key)), args...)
+}
+
+// joinOperatorValues returns all values of the operators as a single space separated string.
+func joinOperatorValues(os []operator.Operator) string {
+	return strings.TrimSpace(strings.Join(operator.GetOperatorValues(os), " "))
+}
+
+// searchText returns the values of the operators matching the keys, along with the remainders, as a single string.
+// Operators with the NOT modifier are ignored. This is useful for sorting by relevance.
+func searchText(ops operator.Operators, keys []string) string {
+	var xs []operator.Operator
+	for _, o := range ops.Get(keys...) {
+		if !o.Has(operator.ModifierNot) {
+			xs = append(xs, o)
+		}
+	}
+	xs = append(xs, ops.Remainders...)
+	return joinOperatorValues(xs)
+}
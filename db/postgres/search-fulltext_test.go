@@ -0,0 +1,60 @@
+package pgutil
+
+import (
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+
+	"github.com/monstercat/golib/operator"
+)
+
+func TestSearchOperatorConfigFullText(t *testing.T) {
+	p, err := operator.NewParser(&operator.ParserConfig{
+		StringStart:  "\"",
+		StringEnd:    "\"",
+		KeyDelimiter: ":",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ft := NewFullTextOperator("search", "english", "q")
+	ops := p.Parse("deadmau5 strobe !q:remix")
+
+	qry := squirrel.Select("*").From("t")
+	ApplyOperators(&qry, []ISearchOperatorConfig{ft}, ops, "t.")
+	ApplySort([]string{"-relevance", "title"}, &qry, map[string]Sort{
+		"relevance": ft.RankSort(ops, "t."),
+		"title":     SimpleSort("t.title"),
+	})
+
+	sql, args, err := qry.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "SELECT * FROM t WHERE ((NOT t.search @@ websearch_to_tsquery($1::regconfig, $2)) OR " +
+		"(t.search @@ websearch_to_tsquery($3::regconfig, $4))) " +
+		"ORDER BY ts_rank(t.search, websearch_to_tsquery($5::regconfig, $6)) DESC, t.title ASC"
+	if sql != expected {
+		t.Errorf("Expected sql %s, got %s", expected, sql)
+	}
+	expectedArgs := []interface{}{"english", "remix", "english", "deadmau5 strobe", "english", "deadmau5 strobe"}
+	if len(args) != len(expectedArgs) {
+		t.Fatalf("Expected args %v, got %v", expectedArgs, args)
+	}
+	for i, a := range args {
+		if a != expectedArgs[i] {
+			t.Errorf("Expected args %v, got %v", expectedArgs, args)
+			break
+		}
+	}
+
+	// No search text means the rank sort is ignored.
+	qry = squirrel.Select("*").From("t")
+	ApplySort([]string{"-relevance"}, &qry, map[string]Sort{
+		"relevance": ft.RankSort(p.Parse(""), "t."),
+	})
+	if sql, _, _ := qry.ToSql(); sql != "SELECT * FROM t" {
+		t.Errorf("Expected no sort, got %s", sql)
+	}
+}
@@ -0,0 +1,59 @@
+package pgutil
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+
+	"github.com/monstercat/golib/operator"
+)
+
+// NewJSONBOperator creates a config which compares the text value at the path within the JSONB field. If no keys are
+// provided, the key defaults to the field and path joined by periods (e.g., metadata.label). Note that the parser
+// needs to allow periods in keys for this to work (see operator.ParserConfig.KeyCharacters).
+func NewJSONBOperator(field string, path []string, keys ...string) SearchOperatorConfigJSONB {
+	if len(keys) == 0 {
+		keys = []string{strings.Join(append([]string{field}, path...), ".")}
+	}
+	return SearchOperatorConfigJSONB{
+		SearchOperatorConfigBase: NewSearchOperatorConfigBase(field, keys...),
+		Path:                     path,
+	}
+}
+
+// SearchOperatorConfigJSONB compares the text value at Path within a JSONB column (Field) to the operator values. For
+// example, a Path of [label] generates
+//
+//	metadata #>> '{label}' = ANY('{foo}')
+//
+// Multiple values in a single operator match any of the values.
+type SearchOperatorConfigJSONB struct {
+	SearchOperatorConfigBase
+
+	// Path to the value within the JSONB column.
+	Path []string
+}
+
+// Sqlizer returns the condition for the operator.
+func (c SearchOperatorConfigJSONB) Sqlizer(o operator.Operator, prefix string) squirrel.Sqlizer {
+	if len(o.Values) == 0 || len(c.Path) == 0 {
+		return nil
+	}
+	var sql squirrel.Sqlizer = squirrel.Expr(
+		fmt.Sprintf("%s%s #>> ? = ANY(?)", prefix, c.Field),
+		pq.StringArray(c.Path),
+		pq.StringArray(o.Values),
+	)
+	if o.Has(operator.ModifierNot) {
+		sql = Not{sql}
+	}
+	return sql
+}
+
+func (c SearchOperatorConfigJSONB) Apply(os, rem []operator.Operator, a *Accumulator, prefix string) {
+	loopOperators(os, a, func(o operator.Operator) squirrel.Sqlizer {
+		return c.Sqlizer(o, prefix)
+	})
+}
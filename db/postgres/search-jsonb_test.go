@@ -0,0 +1,55 @@
+package pgutil
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+
+	"github.com/monstercat/golib/operator"
+)
+
+func TestSearchOperatorConfigJSONB(t *testing.T) {
+	p, err := operator.NewParser(&operator.ParserConfig{
+		StringStart:   "\"",
+		StringEnd:     "\"",
+		KeyDelimiter:  ":",
+		KeyCharacters: ".",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := []ISearchOperatorConfig{
+		NewJSONBOperator("metadata", []string{"label"}),
+	}
+
+	tests := []struct {
+		s    string
+		sql  string
+		args []interface{}
+	}{
+		{
+			s:    "metadata.label:foo |!metadata.label:bar",
+			sql:  "(NOT (t.metadata #>> $1 = ANY($2)) OR (t.metadata #>> $3 = ANY($4)))",
+			args: []interface{}{pq.StringArray{"label"}, pq.StringArray{"bar"}, pq.StringArray{"label"}, pq.StringArray{"foo"}},
+		},
+	}
+
+	for i, test := range tests {
+		qry := squirrel.Select("*").From("t")
+		ApplyOperators(&qry, config, p.Parse(test.s), "t.")
+
+		sql, args, err := qry.PlaceholderFormat(squirrel.Dollar).ToSql()
+		if err != nil {
+			t.Fatalf("[%d] %s", i, err)
+		}
+		if expected := "SELECT * FROM t WHERE " + test.sql; sql != expected {
+			t.Errorf("[%d] Expected sql %s, got %s", i, expected, sql)
+		}
+		if !reflect.DeepEqual(args, test.args) {
+			t.Errorf("[%d] Expected args %v, got %v", i, test.args, args)
+		}
+	}
+}
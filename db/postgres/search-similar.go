@@ -0,0 +1,123 @@
+package pgutil
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+
+	"github.com/monstercat/golib/operator"
+)
+
+func NewSimilarOperator(field string, keys ...string) SearchOperatorConfigSimilar {
+	return SearchOperatorConfigSimilar{SearchOperatorConfigBase: NewSearchOperatorConfigBase(field, keys...)}
+}
+
+// SearchOperatorConfigSimilar performs fuzzy matching through the pg_trgm extension. It applies to both operators and
+// remainders, similarly to SearchOperatorConfigStringLike.
+//
+// If Threshold is not set, the % (or <% for Word) operator is used, which respects the pg_trgm.similarity_threshold
+// (or pg_trgm.word_similarity_threshold) setting and can use trigram indexes. Otherwise, the similarity function is
+// compared directly against the threshold.
+type SearchOperatorConfigSimilar struct {
+	SearchOperatorConfigBase
+
+	// Threshold is the minimum similarity (0 to 1) required for a match.
+	Threshold float64
+
+	// Word uses word similarity, which matches the value against any part of the field. This is better suited to
+	// matching a name within a longer title.
+	Word bool
+}
+
+// Score returns the similarity score between the field and the provided text.
+func (c SearchOperatorConfigSimilar) Score(text string, prefix string) squirrel.Sqlizer {
+	if c.Word {
+		return squirrel.Expr(fmt.Sprintf("word_similarity(?, %s%s)", prefix, c.Field), text)
+	}
+	return squirrel.Expr(fmt.Sprintf("similarity(%s%s, ?)", prefix, c.Field), text)
+}
+
+// Sqlizer returns the match condition for the provided operator. All values must match.
+func (c SearchOperatorConfigSimilar) Sqlizer(o operator.Operator, prefix string) squirrel.Sqlizer {
+	and := squirrel.And{}
+	for _, v := range o.Values {
+		if v == "" {
+			continue
+		}
+		var sql squirrel.Sqlizer
+		switch {
+		case c.Threshold > 0:
+			score, args, _ := c.Score(v, prefix).ToSql()
+			sql = squirrel.Expr(score+" >= ?", append(args, c.Threshold)...)
+		case c.Word:
+			sql = squirrel.Expr(fmt.Sprintf("? <%% %s%s", prefix, c.Field), v)
+		default:
+			sql = squirrel.Expr(fmt.Sprintf("%s%s %% ?", prefix, c.Field), v)
+		}
+		if o.Has(operator.ModifierNot) {
+			sql = Not{sql}
+		}
+		and = append(and, sql)
+	}
+	return simplifyConjunction(and)
+}
+
+func (c SearchOperatorConfigSimilar) Apply(os, rem []operator.Operator, a *Accumulator, prefix string) {
+	fn := func(o operator.Operator) squirrel.Sqlizer {
+		return c.Sqlizer(o, prefix)
+	}
+	loopOperators(os, a, fn)
+	applyRemainders(rem, a, fn)
+}
+
+// ScoreColumn returns a column containing the similarity score against the search text in the operators, which can
+// be added to a query through squirrel.SelectBuilder.Column. Returns nil if there is no search text.
+func (c SearchOperatorConfigSimilar) ScoreColumn(ops operator.Operators, prefix, alias string) squirrel.Sqlizer {
+	text := searchText(ops, c.GetKeys())
+	if text == "" {
+		return nil
+	}
+	sql, args, _ := c.Score(text, prefix).ToSql()
+	return squirrel.Expr(sql+" AS "+alias, args...)
+}
+
+// SimilaritySort returns a Sort which orders by the similarity score against the search text in the operators
+// (both the operators matching the keys and the remainders). Operators with the NOT modifier are ignored.
+//
+// Use a descending sort (e.g., "-similarity") to return the closest matches first.
+func (c SearchOperatorConfigSimilar) SimilaritySort(ops operator.Operators, prefix string) Sort {
+	sort := SimilaritySort{}
+	if text := searchText(ops, c.GetKeys()); text != "" {
+		sort.Score = c.Score(text, prefix)
+	}
+	return sort
+}
+
+// SimilaritySort orders by a similarity score. It implements SqlizerSort.
+type SimilaritySort struct {
+	// Score is the score to sort by. If nil (e.g., there is no search text), the sort is ignored.
+	Score squirrel.Sqlizer
+}
+
+// String returns the order by statement with placeholders. As the score requires arguments, ApplySort uses Sqlizer
+// instead.
+func (s SimilaritySort) String(key string) string {
+	sqlizer := s.Sqlizer(key)
+	if sqlizer == nil {
+		return ""
+	}
+	sql, _, _ := sqlizer.ToSql()
+	return sql
+}
+
+// Sqlizer returns the order by statement along with its arguments.
+func (s SimilaritySort) Sqlizer(key string) squirrel.Sqlizer {
+	if s.Score == nil {
+		return nil
+	}
+	sql, args, err := s.Score.ToSql()
+	if err != nil {
+		return nil
+	}
+	return squirrel.Expr(fmt.Sprintf("%s %s", sql, getDirection(key)), args...)
+}
@@ -0,0 +1,71 @@
+package pgutil
+
+import (
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+
+	"github.com/monstercat/golib/operator"
+)
+
+func TestSearchOperatorConfigSimilar(t *testing.T) {
+	p, err := operator.NewParser(&operator.ParserConfig{
+		StringStart:  "\"",
+		StringEnd:    "\"",
+		KeyDelimiter: ":",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		config SearchOperatorConfigSimilar
+		sql    string
+		args   []interface{}
+	}{
+		{
+			config: NewSimilarOperator("name", "artist"),
+			sql: "SELECT *, similarity(t.name, $1) AS score FROM t WHERE ((t.name % $2) OR (t.name % $3)) " +
+				"ORDER BY similarity(t.name, $4) DESC",
+			args: []interface{}{"deadmou5 strobe", "deadmou5", "strobe", "deadmou5 strobe"},
+		},
+		{
+			config: SearchOperatorConfigSimilar{
+				SearchOperatorConfigBase: NewSearchOperatorConfigBase("name", "artist"),
+				Threshold:                0.4,
+				Word:                     true,
+			},
+			sql: "SELECT *, word_similarity($1, t.name) AS score FROM t " +
+				"WHERE ((word_similarity($2, t.name) >= $3) OR (word_similarity($4, t.name) >= $5)) " +
+				"ORDER BY word_similarity($6, t.name) DESC",
+			args: []interface{}{"deadmou5 strobe", "deadmou5", 0.4, "strobe", 0.4, "deadmou5 strobe"},
+		},
+	}
+
+	for i, test := range tests {
+		ops := p.Parse("artist:deadmou5 strobe")
+		qry := squirrel.Select("*").From("t").Column(test.config.ScoreColumn(ops, "t.", "score"))
+		ApplyOperators(&qry, []ISearchOperatorConfig{test.config}, ops, "t.")
+		ApplySort([]string{"-similarity"}, &qry, map[string]Sort{
+			"similarity": test.config.SimilaritySort(ops, "t."),
+		})
+
+		sql, args, err := qry.PlaceholderFormat(squirrel.Dollar).ToSql()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sql != test.sql {
+			t.Errorf("[%d] Expected sql %s, got %s", i, test.sql, sql)
+		}
+		if len(args) != len(test.args) {
+			t.Errorf("[%d] Expected args %v, got %v", i, test.args, args)
+			continue
+		}
+		for j, a := range args {
+			if a != test.args[j] {
+				t.Errorf("[%d] Expected args %v, got %v", i, test.args, args)
+				break
+			}
+		}
+	}
+}
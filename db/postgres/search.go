@@ -16,6 +16,18 @@ type Accumulator struct {
 	or         squirrel.Or
 	and        squirrel.And
 	remainders squirrel.Or
+	errors     []error
+}
+
+// AddError records an error found while applying an operator (e.g., a value which is not allowed). The operator
+// should be skipped. See ApplyOperatorsWithErrors.
+func (a *Accumulator) AddError(err error) {
+	a.errors = append(a.errors, err)
+}
+
+// Errors returns the errors added through AddError.
+func (a *Accumulator) Errors() []error {
+	return a.errors
 }
 
 func (a *Accumulator) ApplyAnd(sql squirrel.Sqlizer) {
@@ -47,17 +59,32 @@ func (a *Accumulator) GetCondition() squirrel.Sqlizer {
 }
 
 func ApplyOperators(query *squirrel.SelectBuilder, config []ISearchOperatorConfig, ops operator.Operators, prefix string) {
+	accumulateOperators(config, ops, prefix).ApplyToQuery(query)
+}
+
+// ApplyOperatorsWithErrors is the same as ApplyOperators, but also appends any errors reported by the configs (e.g.,
+// values not allowed by SearchOperatorConfigEnum) to ops.Errors.
+func ApplyOperatorsWithErrors(query *squirrel.SelectBuilder, config []ISearchOperatorConfig, ops *operator.Operators, prefix string) {
+	a := accumulateOperators(config, *ops, prefix)
+	ops.Errors = append(ops.Errors, a.Errors()...)
+	a.ApplyToQuery(query)
+}
+
+func accumulateOperators(config []ISearchOperatorConfig, ops operator.Operators, prefix string) *Accumulator {
 	a := &Accumulator{}
 	for _, c := range config {
 		os := ops.Get(c.GetKeys()...)
 		c.Apply(os, ops.Remainders, a, prefix)
 	}
-	a.ApplyToQuery(query)
+	return a
 }
 
 func loopOperators(os []operator.Operator, a *Accumulator, sq func(o operator.Operator) squirrel.Sqlizer) {
 	for _, o := range os {
 		q := sq(o)
+		if q == nil {
+			continue
+		}
 		if o.Has(operator.ModifierOr) {
 			a.ApplyOr(q)
 		} else {
@@ -68,7 +95,9 @@ func loopOperators(os []operator.Operator, a *Accumulator, sq func(o operator.Op
 
 func applyRemainders(rem []operator.Operator, a *Accumulator, sq func(o operator.Operator) squirrel.Sqlizer) {
 	for _, o := range rem {
-		a.ApplyRemainder(sq(o))
+		if q := sq(o); q != nil {
+			a.ApplyRemainder(q)
+		}
 	}
 }
 
@@ -0,0 +1,179 @@
+package pgutil
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+)
+
+var (
+	ErrSortUnknownKey  = errors.New("unknown sort key")
+	ErrSortTooManyKeys = errors.New("too many sort keys")
+)
+
+// SortError is returned by SortSpec when the active sorts are invalid.
+type SortError struct {
+	// The error in question
+	Base error
+
+	// Key which caused the error.
+	Key string
+}
+
+// Error string
+func (e *SortError) Error() string {
+	return fmt.Sprintf("Sort key '%s' is invalid. %s", e.Key, e.Base)
+}
+
+// Unwrap returns the base error.
+func (e *SortError) Unwrap() error {
+	return e.Base
+}
+
+// NullsOrder defines where NULL values are placed in the sort.
+type NullsOrder string
+
+const (
+	NullsDefault NullsOrder = ""
+	NullsFirst   NullsOrder = "NULLS FIRST"
+	NullsLast    NullsOrder = "NULLS LAST"
+)
+
+// NullsSort is a SimpleSort with NULLS FIRST or NULLS LAST.
+type NullsSort struct {
+	Field SimpleSort
+	Nulls NullsOrder
+}
+
+func (s NullsSort) String(key string) string {
+	str := s.Field.String(key)
+	if s.Nulls == NullsDefault {
+		return str
+	}
+	return str + " " + string(s.Nulls)
+}
+
+// ActiveSort is a sort key that has been resolved by SortSpec.
+type ActiveSort struct {
+	// Key as provided, including the direction (e.g., -title).
+	Key string
+
+	// Sort the key resolves to.
+	Sort Sort
+}
+
+// Direction of the sort.
+func (s ActiveSort) Direction() Direction {
+	return getDirection(s.Key)
+}
+
+// Column returns the primary column being sorted, if it is known.
+func (s ActiveSort) Column() (string, bool) {
+	switch v := s.Sort.(type) {
+	case SimpleSort:
+		return string(v), true
+	case ExtendedSort:
+		return string(v.Field), true
+	case NullsSort:
+		return string(v.Field), true
+	}
+	return "", false
+}
+
+// Sqlizer returns the order by statement. Returns nil if the sort should be ignored.
+func (s ActiveSort) Sqlizer() squirrel.Sqlizer {
+	return sortSqlizer(s.Sort, s.Key)
+}
+
+// SortSpec validates and applies sort keys. Unlike ApplySort, unknown keys result in an error, the number of keys can
+// be capped, and a unique Tiebreaker column is always appended so that the order is stable across pages.
+type SortSpec struct {
+	// Sorts maps the (lower case) sort key to the sort.
+	Sorts map[string]Sort
+
+	// MaxKeys is the maximum number of sort keys allowed. Zero means unlimited.
+	MaxKeys int
+
+	// Tiebreaker is a unique column (e.g., t.id) appended to every sort. It uses the direction of the last sort key so
+	// that all columns can be compared as a single row for keyset pagination.
+	Tiebreaker string
+
+	// Default sort keys used if no sort keys are provided.
+	Default []string
+}
+
+// Resolve validates the active sorts and returns the resolved sorts, including the Tiebreaker. Empty keys are
+// ignored, as are repeated keys.
+func (s *SortSpec) Resolve(activeSorts []string) ([]ActiveSort, error) {
+	keys := make([]string, 0, len(activeSorts))
+	for _, k := range activeSorts {
+		if k != "" {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		keys = s.Default
+	}
+
+	seen := make(map[string]bool)
+	xs := make([]ActiveSort, 0, len(keys)+1)
+	var hasTiebreaker bool
+	for _, k := range keys {
+		sortKey := getSortKey(k)
+		if seen[sortKey] {
+			continue
+		}
+		seen[sortKey] = true
+
+		v, ok := s.Sorts[sortKey]
+		if !ok {
+			return nil, &SortError{Base: ErrSortUnknownKey, Key: k}
+		}
+		if s.MaxKeys > 0 && len(xs) >= s.MaxKeys {
+			return nil, &SortError{Base: ErrSortTooManyKeys, Key: k}
+		}
+
+		a := ActiveSort{Key: k, Sort: v}
+		if col, ok := a.Column(); ok && col == s.Tiebreaker {
+			hasTiebreaker = true
+		}
+		xs = append(xs, a)
+	}
+
+	if s.Tiebreaker != "" && !hasTiebreaker {
+		key := s.Tiebreaker
+		if len(xs) > 0 && xs[len(xs)-1].Direction() == Desc {
+			key = "-" + key
+		}
+		xs = append(xs, ActiveSort{Key: key, Sort: SimpleSort(s.Tiebreaker)})
+	}
+	return xs, nil
+}
+
+// Sqlizers returns the order by statements for the active sorts.
+func (s *SortSpec) Sqlizers(activeSorts []string) ([]squirrel.Sqlizer, error) {
+	xs, err := s.Resolve(activeSorts)
+	if err != nil {
+		return nil, err
+	}
+	sqlizers := make([]squirrel.Sqlizer, 0, len(xs))
+	for _, x := range xs {
+		if sqlizer := x.Sqlizer(); sqlizer != nil {
+			sqlizers = append(sqlizers, sqlizer)
+		}
+	}
+	return sqlizers, nil
+}
+
+// Apply validates the active sorts and applies them to the query. The query is not modified if an error is returned.
+func (s *SortSpec) Apply(activeSorts []string, query *squirrel.SelectBuilder) error {
+	sqlizers, err := s.Sqlizers(activeSorts)
+	if err != nil {
+		return err
+	}
+	for _, sqlizer := range sqlizers {
+		*query = query.OrderByClause(sqlizer)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package pgutil
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+)
+
+func TestSortSpec_Apply(t *testing.T) {
+	spec := &SortSpec{
+		Sorts: map[string]Sort{
+			"title":    SimpleSort("t.title"),
+			"released": NullsSort{Field: "t.release_date", Nulls: NullsLast},
+			"id":       SimpleSort("t.id"),
+		},
+		MaxKeys:    2,
+		Tiebreaker: "t.id",
+		Default:    []string{"title"},
+	}
+
+	tests := []struct {
+		sorts []string
+		sql   string
+		err   error
+	}{
+		{
+			sorts: nil,
+			sql:   "SELECT * FROM t ORDER BY t.title ASC, t.id ASC",
+		},
+		{
+			sorts: []string{"-released", "", "-released"},
+			sql:   "SELECT * FROM t ORDER BY t.release_date DESC NULLS LAST, t.id DESC",
+		},
+		{
+			sorts: []string{"-id"},
+			sql:   "SELECT * FROM t ORDER BY t.id DESC",
+		},
+		{
+			sorts: []string{"title", "-"},
+			err:   ErrSortUnknownKey,
+		},
+		{
+			sorts: []string{"titel"},
+			err:   ErrSortUnknownKey,
+		},
+		{
+			sorts: []string{"title", "released", "id"},
+			err:   ErrSortTooManyKeys,
+		},
+	}
+
+	for i, test := range tests {
+		qry := squirrel.Select("*").From("t")
+		err := spec.Apply(test.sorts, &qry)
+		if test.err != nil {
+			if !errors.Is(err, test.err) {
+				t.Errorf("[%d] Expected error %s, got %v", i, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("[%d] %s", i, err)
+		}
+
+		sql, _, err := qry.ToSql()
+		if err != nil {
+			t.Fatalf("[%d] %s", i, err)
+		}
+		if sql != test.sql {
+			t.Errorf("[%d] Expected sql %s, got %s", i, test.sql, sql)
+		}
+	}
+}
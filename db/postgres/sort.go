@@ -18,6 +18,13 @@ type Sort interface {
 	String(string) string
 }
 
+// SqlizerSort is an optional interface for a Sort which requires arguments (e.g., sorting by relevance to a search
+// term). ApplySort will use Sqlizer instead of String if it is implemented. A nil result is ignored.
+type SqlizerSort interface {
+	Sort
+	Sqlizer(key string) squirrel.Sqlizer
+}
+
 type SimpleSort string
 
 func (s SimpleSort) String(key string) string {
@@ -38,17 +45,31 @@ func (s ExtendedSort) String(key string) string {
 }
 
 func getDirection(key string) Direction {
-	if key[0] == '-' {
+	if strings.HasPrefix(key, "-") {
 		return Desc
 	}
 	return Asc
 }
 
 func getSortKey(key string) string {
-	if key[0] == '-' {
-		return strings.ToLower(key[1:])
+	return strings.ToLower(strings.TrimPrefix(key, "-"))
+}
+
+// sortSqlizer returns the order by statement for the sort. Returns nil if the sort should be ignored.
+func sortSqlizer(v Sort, key string) squirrel.Sqlizer {
+	ss, ok := v.(SqlizerSort)
+	if !ok {
+		return squirrel.Expr(v.String(key))
 	}
-	return strings.ToLower(key)
+	sqlizer := ss.Sqlizer(key)
+	if sqlizer == nil {
+		return nil
+	}
+	sql, args, err := sqlizer.ToSql()
+	if err != nil || sql == "" {
+		return nil
+	}
+	return squirrel.Expr(sql, args...)
 }
 
 func ApplySortStrings(xs []string, query *squirrel.SelectBuilder, sorts map[string]string) {
@@ -69,6 +90,8 @@ func ApplySort(activeSorts []string, query *squirrel.SelectBuilder, possibleSort
 		if !ok {
 			continue
 		}
-		*query = query.OrderBy(v.String(s))
+		if sqlizer := sortSqlizer(v, s); sqlizer != nil {
+			*query = query.OrderByClause(sqlizer)
+		}
 	}
 }
\ No newline at end of file
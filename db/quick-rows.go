@@ -1,6 +1,8 @@
 package dbutil
 
 import (
+	"context"
+
 	"github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
 )
@@ -12,7 +14,9 @@ func QuickRows(db sqlx.Queryer, qry squirrel.SelectBuilder, fn RowHandler) error
 	if err != nil {
 		return err
 	}
+	_, done := StartQuery(context.Background(), OperationQuery, query, args)
 	rows, err := db.Queryx(query, args...)
+	done(-1, err)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,158 @@
+package dbutil
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"github.com/monstercat/golib/logger"
+)
+
+const (
+	ErrCodeSerializationFailure pq.ErrorCode = "40001"
+	ErrCodeDeadlockDetected     pq.ErrorCode = "40P01"
+)
+
+// IsRetryable returns true if the error is a serialization failure or a deadlock, in which case the transaction can
+// be retried.
+func IsRetryable(err error) bool {
+	var perr *pq.Error
+	if !errors.As(err, &perr) {
+		return false
+	}
+	return perr.Code == ErrCodeSerializationFailure || perr.Code == ErrCodeDeadlockDetected
+}
+
+// RetryConfig configures TxRetry. The zero value is usable.
+type RetryConfig struct {
+	// Options used to begin each transaction, e.g., the isolation level.
+	Options *sql.TxOptions
+
+	// MaxAttempts is the maximum number of times the function is run. Defaults to 3.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry. Defaults to 50ms.
+	InitialBackoff time.Duration
+
+	// MaxBackoff is the maximum delay between retries. Defaults to 2s.
+	MaxBackoff time.Duration
+
+	// Multiplier is applied to the delay after each retry. Defaults to 2.
+	Multiplier float64
+
+	// Jitter is the fraction of the delay which is randomized, between 0 and 1. Negative values disable jitter.
+	// Defaults to 0.5, meaning the delay is between 50% and 100% of the backoff.
+	Jitter float64
+
+	// Retryable returns true if the error should be retried. Defaults to IsRetryable.
+	Retryable func(err error) bool
+
+	// OnRetry is called before each retry.
+	OnRetry func(attempt int, delay time.Duration, err error)
+
+	// Logger, if provided, logs each retry with SeverityWarning.
+	Logger logger.Logger
+}
+
+func (c *RetryConfig) maxAttempts() int {
+	if c.MaxAttempts <= 0 {
+		return 3
+	}
+	return c.MaxAttempts
+}
+
+func (c *RetryConfig) retryable(err error) bool {
+	if c.Retryable != nil {
+		return c.Retryable(err)
+	}
+	return IsRetryable(err)
+}
+
+// Backoff returns the delay before the retry following the provided attempt (starting from 1).
+func (c *RetryConfig) Backoff(attempt int) time.Duration {
+	initial, max, multiplier, jitter := c.InitialBackoff, c.MaxBackoff, c.Multiplier, c.Jitter
+	if initial <= 0 {
+		initial = 50 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 2 * time.Second
+	}
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+	if jitter == 0 {
+		jitter = 0.5
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+
+	delay := float64(initial)
+	for i := 1; i < attempt && delay < float64(max); i++ {
+		delay *= multiplier
+	}
+	if delay > float64(max) {
+		delay = float64(max)
+	}
+	if jitter > 0 {
+		delay -= delay * jitter * rand.Float64()
+	}
+	return time.Duration(delay)
+}
+
+// TxBeginner begins a sqlx transaction. It is implemented by *sqlx.DB.
+type TxBeginner interface {
+	BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error)
+}
+
+// TxRetry runs the function in a transaction, retrying it with a new transaction if it fails with an error which is
+// retryable (by default, serialization failures and deadlocks). Errors from beginning and committing are also retried.
+// The last error is returned once the attempts are exhausted, or the context's error if it is done while waiting.
+func TxRetry(ctx context.Context, db TxBeginner, cfg RetryConfig, fn TxFunc) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = txOnce(ctx, db, cfg.Options, fn)
+		if err == nil || attempt >= cfg.maxAttempts() || !cfg.retryable(err) {
+			return err
+		}
+
+		delay := cfg.Backoff(attempt)
+		if cfg.OnRetry != nil {
+			cfg.OnRetry(attempt, delay, err)
+		}
+		if cfg.Logger != nil {
+			cfg.Logger.Log(logger.SeverityWarning, logger.NewContextualPayload("Retrying transaction").Add(
+				map[string]interface{}{
+					"Attempt": attempt,
+					"Delay":   delay.String(),
+					"Error":   err.Error(),
+				},
+			))
+		}
+
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+func txOnce(ctx context.Context, db TxBeginner, opts *sql.TxOptions, fn TxFunc) error {
+	tx, err := db.BeginTxx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,222 @@
+package dbutil
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{err: &pq.Error{Code: ErrCodeSerializationFailure}, expected: true},
+		{err: fmt.Errorf("wrapped: %w", &pq.Error{Code: ErrCodeDeadlockDetected}), expected: true},
+		{err: &pq.Error{Code: "23505"}, expected: false},
+		{err: errors.New("other"), expected: false},
+		{err: nil, expected: false},
+	}
+	for i, test := range tests {
+		if IsRetryable(test.err) != test.expected {
+			t.Errorf("[%d] Expected %t for %v", i, test.expected, test.err)
+		}
+	}
+}
+
+func TestRetryConfig_Backoff(t *testing.T) {
+	cfg := RetryConfig{
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     35 * time.Millisecond,
+		Jitter:         -1,
+	}
+	expected := []time.Duration{10, 20, 35, 35}
+	for i, e := range expected {
+		if d := cfg.Backoff(i + 1); d != e*time.Millisecond {
+			t.Errorf("[%d] Expected %s, got %s", i, e*time.Millisecond, d)
+		}
+	}
+
+	cfg.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := cfg.Backoff(1)
+		if d < 5*time.Millisecond || d > 10*time.Millisecond {
+			t.Fatalf("Expected delay between 5ms and 10ms, got %s", d)
+		}
+	}
+}
+
+// fakeDriver is a database/sql driver whose transactions do nothing. Commits fail with the errors in commitErrs, in
+// order, until they are exhausted. Executed statements are recorded, and fail with the error in execErrs, if any.
+type fakeDriver struct {
+	begins     int
+	commits    int
+	rollbacks  int
+	commitErrs []error
+	execs      []string
+	execErrs   map[string]error
+}
+
+func (d *fakeDriver) Connect(context.Context) (driver.Conn, error) { return fakeConn{d}, nil }
+func (d *fakeDriver) Driver() driver.Driver                        { return d }
+func (d *fakeDriver) Open(string) (driver.Conn, error)             { return fakeConn{d}, nil }
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error) {
+	c.d.begins++
+	return fakeTx(c), nil
+}
+
+func (c fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.d.execs = append(c.d.execs, query)
+	if err := c.d.execErrs[query]; err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(0), nil
+}
+
+type fakeTx struct {
+	d *fakeDriver
+}
+
+func (t fakeTx) Commit() error {
+	t.d.commits++
+	if len(t.d.commitErrs) == 0 {
+		return nil
+	}
+	err := t.d.commitErrs[0]
+	t.d.commitErrs = t.d.commitErrs[1:]
+	return err
+}
+
+func (t fakeTx) Rollback() error {
+	t.d.rollbacks++
+	return nil
+}
+
+func newFakeDb(t *testing.T) (*sqlx.DB, *fakeDriver) {
+	d := &fakeDriver{}
+	db := sqlx.NewDb(sql.OpenDB(d), "postgres")
+	t.Cleanup(func() {
+		db.Close()
+	})
+	return db, d
+}
+
+func TestTxRetry(t *testing.T) {
+	retryErr := &pq.Error{Code: ErrCodeSerializationFailure}
+	otherErr := errors.New("other")
+
+	type retry struct {
+		attempt int
+		err     error
+	}
+
+	tests := []struct {
+		// Errors returned by fn in each attempt. Once exhausted, fn succeeds.
+		errs       []error
+		commitErrs []error
+		expected   error
+		attempts   int
+		retries    int
+		rollbacks  int
+	}{
+		// Succeeds on the first attempt.
+		{attempts: 1},
+
+		// Fails and then succeeds.
+		{errs: []error{retryErr, retryErr}, attempts: 3, retries: 2, rollbacks: 2},
+
+		// Exceeds the maximum number of attempts.
+		{errs: []error{retryErr, retryErr, retryErr, retryErr}, expected: retryErr, attempts: 3, retries: 2, rollbacks: 3},
+
+		// Not retryable.
+		{errs: []error{otherErr}, expected: otherErr, attempts: 1, rollbacks: 1},
+
+		// Commit errors are also retried.
+		{commitErrs: []error{retryErr}, attempts: 2, retries: 1},
+	}
+
+	for i, test := range tests {
+		db, d := newFakeDb(t)
+		d.commitErrs = test.commitErrs
+
+		var retries []retry
+		l := &recordingLogger{}
+		cfg := RetryConfig{
+			InitialBackoff: time.Millisecond,
+			Jitter:         -1,
+			Logger:         l,
+		}
+		cfg.OnRetry = func(attempt int, delay time.Duration, err error) {
+			if delay != cfg.Backoff(attempt) {
+				t.Errorf("[%d] Unexpected delay %s for attempt %d", i, delay, attempt)
+			}
+			retries = append(retries, retry{attempt, err})
+		}
+
+		attempts := 0
+		err := TxRetry(context.Background(), db, cfg, func(tx *sqlx.Tx) error {
+			attempts++
+			if tx == nil {
+				t.Fatalf("[%d] Expecting a transaction", i)
+			}
+			if attempts <= len(test.errs) {
+				return test.errs[attempts-1]
+			}
+			return nil
+		})
+
+		if !errors.Is(err, test.expected) || (test.expected == nil && err != nil) {
+			t.Errorf("[%d] Expected error %v, got %v", i, test.expected, err)
+		}
+		if attempts != test.attempts || d.begins != test.attempts {
+			t.Errorf("[%d] Expected %d attempts, got %d (%d transactions)", i, test.attempts, attempts, d.begins)
+		}
+		if d.rollbacks != test.rollbacks {
+			t.Errorf("[%d] Expected %d rollbacks, got %d", i, test.rollbacks, d.rollbacks)
+		}
+		if len(retries) != test.retries || len(l.logs) != test.retries {
+			t.Errorf("[%d] Expected %d retries, got %d (%d logs)", i, test.retries, len(retries), len(l.logs))
+		}
+		for j, r := range retries {
+			if r.attempt != j+1 || !errors.Is(r.err, retryErr) {
+				t.Errorf("[%d] Unexpected retry %d: %#v", i, j, r)
+			}
+		}
+	}
+}
+
+func TestTxRetry_Context(t *testing.T) {
+	db, d := newFakeDb(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cfg := RetryConfig{
+		InitialBackoff: time.Hour,
+		Jitter:         -1,
+		OnRetry: func(int, time.Duration, error) {
+			cancel()
+		},
+	}
+	err := TxRetry(ctx, db, cfg, func(tx *sqlx.Tx) error {
+		return &pq.Error{Code: ErrCodeDeadlockDetected}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if d.begins != 1 {
+		t.Errorf("Expected 1 attempt, got %d", d.begins)
+	}
+}
@@ -0,0 +1,76 @@
+package dbutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+
+	golibErrors "github.com/monstercat/golib/errors"
+)
+
+var (
+	ErrUnsupportedExt = errors.New("connection must be a *sqlx.DB or *sqlx.Tx")
+)
+
+// savepointCounter is used to generate unique savepoint names.
+var savepointCounter uint64
+
+// NewSavepointName returns a unique savepoint name.
+func NewSavepointName() string {
+	return "sp_" + strconv.FormatUint(atomic.AddUint64(&savepointCounter, 1), 10)
+}
+
+// CreateSavepoint creates a savepoint with the provided name.
+func CreateSavepoint(ctx context.Context, tx sqlx.ExecerContext, name string) error {
+	_, err := tx.ExecContext(ctx, "SAVEPOINT "+name)
+	return err
+}
+
+// RollbackToSavepoint undoes all changes made since the savepoint was created. The savepoint is released.
+func RollbackToSavepoint(ctx context.Context, tx sqlx.ExecerContext, name string) error {
+	if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); err != nil {
+		return err
+	}
+	return ReleaseSavepoint(ctx, tx, name)
+}
+
+// ReleaseSavepoint releases the savepoint, keeping the changes made since it was created.
+func ReleaseSavepoint(ctx context.Context, tx sqlx.ExecerContext, name string) error {
+	_, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	return err
+}
+
+// TxSavepoint runs the function inside a savepoint of an existing transaction. If the function fails, changes made by
+// it are rolled back and its error is returned, leaving the transaction usable. If rolling back fails as well, the
+// transaction is left aborted and both errors are returned as errors.Errors. Otherwise, the savepoint is released.
+func TxSavepoint(tx *sqlx.Tx, fn TxFunc) error {
+	ctx := context.Background()
+	name := NewSavepointName()
+	if err := CreateSavepoint(ctx, tx, name); err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		if rbErr := RollbackToSavepoint(ctx, tx, name); rbErr != nil {
+			return golibErrors.Errors{err, fmt.Errorf("rollback to savepoint %s: %w", name, rbErr)}
+		}
+		return err
+	}
+	return ReleaseSavepoint(ctx, tx, name)
+}
+
+// TxNested runs the function in a transaction. If ext is already a transaction, a savepoint is used through
+// TxSavepoint so that functions using transactions can be composed. Otherwise, a new transaction is started through
+// TxNow.
+func TxNested(ext sqlx.Ext, fn TxFunc) error {
+	switch v := ext.(type) {
+	case *sqlx.Tx:
+		return TxSavepoint(v, fn)
+	case *sqlx.DB:
+		return TxNow(v, fn)
+	}
+	return ErrUnsupportedExt
+}
@@ -0,0 +1,41 @@
+package dbutil
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestTxSavepoint(t *testing.T) {
+	db, d := newFakeDb(t)
+	tx, err := db.Beginx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	fnErr := errors.New("failed")
+	if err := TxSavepoint(tx, func(*sqlx.Tx) error { return fnErr }); err != fnErr {
+		t.Errorf("Expecting the error of the function, got %v", err)
+	}
+	if len(d.execs) != 3 ||
+		!strings.HasPrefix(d.execs[0], "SAVEPOINT ") ||
+		!strings.HasPrefix(d.execs[1], "ROLLBACK TO SAVEPOINT ") ||
+		!strings.HasPrefix(d.execs[2], "RELEASE SAVEPOINT ") {
+		t.Errorf("Unexpected statements %v", d.execs)
+	}
+
+	// If rolling back fails, both errors are returned.
+	rbErr := errors.New("rollback failed")
+	d.execs = nil
+	d.execErrs = map[string]error{}
+	err = TxSavepoint(tx, func(*sqlx.Tx) error {
+		d.execErrs["ROLLBACK TO SAVEPOINT "+strings.TrimPrefix(d.execs[0], "SAVEPOINT ")] = rbErr
+		return fnErr
+	})
+	if !errors.Is(err, fnErr) || !errors.Is(err, rbErr) {
+		t.Errorf("Expecting both errors, got %v", err)
+	}
+}
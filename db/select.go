@@ -12,6 +12,10 @@ import (
 const SelectTagName = "select"
 const SelectSetTagName = "select-sets"
 
+// SelectJoinTagName marks a struct field whose columns come from a joined table. The value of the tag is the alias of
+// the joined table, used as the prefix of its columns. See GetColumnFields.
+const SelectJoinTagName = "select-join"
+
 type Coalescer func(reflect.Type, *SelectTags) string
 
 var CoalesceFromType Coalescer = DefaultCoalescer
@@ -164,6 +168,89 @@ func extractColumnName(set string, it SelectIterator, invert bool, filterFields.
 	}
 }
 
+// columnFieldIterator is called for each column found through columnIteration. The index is the index of the field
+// from the root struct, and join is the alias of the joined struct containing the field, if any.
+type columnFieldIterator func(name string, f reflect.StructField, t *SelectTags, index []int, join string)
+
+// columnIteration finds the columns of a struct for GetColumnFields. Unlike extractColumnName, it descends into the
+// structs tagged with SelectJoinTagName, and embedded structs are iterated through a new value of their type, as the
+// values of unexported fields cannot be retrieved.
+type columnIteration struct {
+	set string
+	it  columnFieldIterator
+}
+
+func (c *columnIteration) iterator(index []int, join string) StructFieldIterator {
+	return func(f reflect.StructField, v reflect.Value) {
+		t := &SelectTags{}
+		t.Parse(f.Tag.Get(SelectTagName))
+		t.ParseSets(f.Tag.Get(SelectSetTagName))
+		if t.Ignore {
+			return
+		}
+
+		idx := append(index[:len(index):len(index)], f.Index...)
+		if alias := f.Tag.Get(SelectJoinTagName); alias != "" {
+			if f.Type.Kind() == reflect.Struct {
+				IterateStructFields(reflect.New(f.Type).Interface(), c.iterator(idx, alias))
+			}
+			return
+		}
+
+		if f.Anonymous {
+			if f.Type.Kind() == reflect.Struct {
+				IterateStructFields(reflect.New(f.Type).Interface(), c.iterator(idx, join))
+				return
+			}
+			vv := v.Interface()
+			IterateStructFields(&vv, c.iterator(idx, join))
+			return
+		}
+
+		if c.set != "" && !t.ContainsSet(c.set) {
+			return
+		}
+
+		name := ColumnNameFromDbTag(f)
+		if name == "" {
+			return
+		}
+		c.it(name, f, t, idx, join)
+	}
+}
+
+// ColumnField is a column returned by GetColumnFields, along with the index of the struct field (as used by
+// reflect.Value.FieldByIndex) that it should be scanned into.
+type ColumnField struct {
+	Column string
+	Index  []int
+}
+
+// GetColumnFields returns the columns of the set, in the same order as GetColumnsForSet, along with the field each
+// column maps to. As they cannot be scanned into, columns of unexported fields are excluded. Struct fields tagged with
+// SelectJoinTagName are not columns themselves; their columns are included instead, prefixed with the alias in the tag.
+func GetColumnFields(set string, val interface{}, prefix string) []ColumnField {
+	var xs []ColumnField
+	c := &columnIteration{
+		set: set,
+		it: func(name string, f reflect.StructField, t *SelectTags, index []int, join string) {
+			if f.PkgPath != "" {
+				return
+			}
+			p := prefix
+			if join != "" {
+				p = join
+			}
+			xs = append(xs, ColumnField{
+				Column: t.Apply(name, p, f.Type),
+				Index:  index,
+			})
+		},
+	}
+	IterateStructFields(val, c.iterator(nil, ""))
+	return xs
+}
+
 func GetColumnsByTag(val interface{}, prefix string, filterFields ...string) map[string]string {
 	return getColumnsByTag(val, prefix, false, filterFields...)
 }
@@ -88,4 +88,61 @@ func TestGetColumnsByTag(t *testing.T) {
 
 	// Second time should get from cache!
 	testCols(GetColumnsByTag(&rp, ""))
-}
\ No newline at end of file
+}
+
+func TestGetColumnFields(t *testing.T) {
+	type label struct {
+		Id    string `db:"id"`
+		Title string `db:"title"`
+	}
+	type Base struct {
+		Id      string `db:"id"`
+		Created time.Time
+	}
+	type release struct {
+		Base
+		Title string `db:"title" select-sets:"full"`
+		UPC   string `db:"upc" select:"coalesce"`
+		Label label  `select-join:"l"`
+		cache string
+	}
+
+	var r release
+	fields := GetColumnFields("", &r, "t")
+	expected := []string{"t.id", "t.created", "t.title", "COALESCE(t.upc, '') as upc", "l.id", "l.title"}
+	if len(fields) != len(expected) {
+		t.Fatalf("Expected %d columns, got %v", len(expected), fields)
+	}
+	for i, f := range fields {
+		if f.Column != expected[i] {
+			t.Errorf("[%d] Expected %s, got %s", i, expected[i], f.Column)
+		}
+	}
+	if idx := fields[4].Index; len(idx) != 2 || idx[0] != 3 || idx[1] != 0 {
+		t.Errorf("Expected index [3 0], got %v", idx)
+	}
+
+	// The columns of a set match GetColumnsForSet.
+	cols := GetColumnsForSet("full", &r, "t")
+	fields = GetColumnFields("full", &r, "t")
+	if len(cols) != 1 || len(fields) != 1 || cols[0] != fields[0].Column {
+		t.Errorf("Expected columns to match, got %v and %v", cols, fields)
+	}
+
+	// Embedded structs of unexported types.
+	type base struct {
+		Id string `db:"id"`
+	}
+	type track struct {
+		base
+		Title string `db:"title"`
+	}
+	var tr track
+	fields = GetColumnFields("", &tr, "")
+	if len(fields) != 2 || fields[0].Column != "id" || fields[1].Column != "title" {
+		t.Fatalf("Expected columns id and title, got %v", fields)
+	}
+	if idx := fields[0].Index; len(idx) != 2 || idx[0] != 0 || idx[1] != 0 {
+		t.Errorf("Expected index [0 0], got %v", idx)
+	}
+}
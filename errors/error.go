@@ -1,6 +1,9 @@
 package errors
 
-import "strings"
+import (
+	"errors"
+	"strings"
+)
 
 type Errors []error
 
@@ -15,3 +18,31 @@ func (e Errors) Error() string {
 func (e *Errors) AddError(err error) {
 	*e = append(*e, err)
 }
+
+// Is returns true if any of the errors matches the target, so that errors.Is can be used on the aggregate.
+func (e Errors) Is(target error) bool {
+	for _, ee := range e {
+		if errors.Is(ee, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error which matches the target, so that errors.As can be used on the aggregate.
+func (e Errors) As(target interface{}) bool {
+	for _, ee := range e {
+		if errors.As(ee, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Err returns nil if there are no errors, otherwise the errors themselves.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
@@ -0,0 +1,85 @@
+package operator
+
+import "strings"
+
+// Comparator defines how the values of an operator should be compared.
+type Comparator string
+
+// Available comparators. ComparatorNone denotes that the value should be handled as-is (e.g., equality, or the
+// default matching behaviour of the consumer).
+const (
+	ComparatorNone  Comparator = ""
+	ComparatorEq    Comparator = "="
+	ComparatorGt    Comparator = ">"
+	ComparatorGte   Comparator = ">="
+	ComparatorLt    Comparator = "<"
+	ComparatorLte   Comparator = "<="
+	ComparatorRange Comparator = ".."
+)
+
+// Order matters - longer prefixes must be checked first.
+var prefixComparators = []Comparator{
+	ComparatorGte, ComparatorLte, ComparatorGt, ComparatorLt, ComparatorEq,
+}
+
+// ParseComparator extracts a comparator from the provided value. The following forms are recognized:
+//
+//	>1000          ComparatorGt, [1000]
+//	>=1000         ComparatorGte, [1000]
+//	<1000          ComparatorLt, [1000]
+//	<=1000         ComparatorLte, [1000]
+//	=1000          ComparatorEq, [1000]
+//	2023..2024     ComparatorRange, [2023, 2024]
+//	2023..         ComparatorRange, [2023, ""]
+//	..2024         ComparatorRange, ["", 2024]
+//
+// Any other value results in ComparatorNone, with the value returned as is. This includes a comparator without an
+// operand (e.g., >=), an operand starting with another comparator (e.g., <>1) and values containing more than one ..
+// (e.g., 1..2..3).
+func ParseComparator(value string) (Comparator, []string) {
+	for _, c := range prefixComparators {
+		if !strings.HasPrefix(value, string(c)) {
+			continue
+		}
+		operand := value[len(c):]
+		if operand == "" || strings.ContainsAny(operand[:1], "<>=") {
+			return ComparatorNone, []string{value}
+		}
+		return c, []string{operand}
+	}
+	if value != string(ComparatorRange) && strings.Count(value, string(ComparatorRange)) == 1 {
+		idx := strings.Index(value, string(ComparatorRange))
+		return ComparatorRange, []string{value[:idx], value[idx+len(ComparatorRange):]}
+	}
+	return ComparatorNone, []string{value}
+}
+
+// ParserDelegateComparators is an optional interface allowing the delegate to extract a comparator from each value.
+type ParserDelegateComparators interface {
+	// ParseValue returns the comparator and the values without the comparator syntax. If comparators are not enabled
+	// for the key, or the value was quoted, ComparatorNone should be returned with the value as is.
+	ParseValue(key, value string, quoted bool) (Comparator, []string)
+
+	// IsQuoted returns true if the value of the match (as passed to ParserRegexpDelegate.ParseMatch) was enclosed in the
+	// string start and string end.
+	IsQuoted(match []string) bool
+}
+
+// EncodeComparator is the inverse of ParseComparator. It returns the values with the comparator syntax applied. For
+// ComparatorRange, the two values are combined into a single value.
+func EncodeComparator(c Comparator, values []string) []string {
+	switch c {
+	case ComparatorNone:
+		return values
+	case ComparatorRange:
+		if len(values) != 2 {
+			return values
+		}
+		return []string{values[0] + string(ComparatorRange) + values[1]}
+	}
+	xs := make([]string, 0, len(values))
+	for _, v := range values {
+		xs = append(xs, string(c)+v)
+	}
+	return xs
+}
@@ -0,0 +1,149 @@
+package operator
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestParseComparator(t *testing.T) {
+	tests := []struct {
+		s          string
+		comparator Comparator
+		values     []string
+	}{
+		{s: "1000", comparator: ComparatorNone, values: []string{"1000"}},
+		{s: ">1000", comparator: ComparatorGt, values: []string{"1000"}},
+		{s: ">=1000", comparator: ComparatorGte, values: []string{"1000"}},
+		{s: "<1000", comparator: ComparatorLt, values: []string{"1000"}},
+		{s: "<=1000", comparator: ComparatorLte, values: []string{"1000"}},
+		{s: "=1000", comparator: ComparatorEq, values: []string{"1000"}},
+		{s: ">", comparator: ComparatorNone, values: []string{">"}},
+		{s: "2023-01-01..2023-06-30", comparator: ComparatorRange, values: []string{"2023-01-01", "2023-06-30"}},
+		{s: "2023-01-01..", comparator: ComparatorRange, values: []string{"2023-01-01", ""}},
+		{s: "..2023-06-30", comparator: ComparatorRange, values: []string{"", "2023-06-30"}},
+		{s: "..", comparator: ComparatorNone, values: []string{".."}},
+		{s: ">=", comparator: ComparatorNone, values: []string{">="}},
+		{s: "<=", comparator: ComparatorNone, values: []string{"<="}},
+		{s: "<>1", comparator: ComparatorNone, values: []string{"<>1"}},
+		{s: "1..2..3", comparator: ComparatorNone, values: []string{"1..2..3"}},
+		{s: "../a/..", comparator: ComparatorNone, values: []string{"../a/.."}},
+	}
+
+	for i, test := range tests {
+		comparator, values := ParseComparator(test.s)
+		if comparator != test.comparator {
+			t.Errorf("[%d] Expected comparator %s, got %s", i, test.comparator, comparator)
+		}
+		if len(values) != len(test.values) {
+			t.Errorf("[%d] Expected values %v, got %v", i, test.values, values)
+			continue
+		}
+		for j, v := range values {
+			if v != test.values[j] {
+				t.Errorf("[%d] Expected values %v, got %v", i, test.values, values)
+			}
+		}
+	}
+}
+
+func TestParser_ParseComparators(t *testing.T) {
+	p, err := NewParser(&ParserConfig{
+		StringStart:    "\"",
+		StringEnd:      "\"",
+		KeyDelimiter:   ":",
+		ComparatorKeys: []string{"plays", "released"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Operators{
+		Values: map[string][]Operator{
+			"plays": {{
+				Values:     []string{"1000"},
+				Comparator: ComparatorGt,
+			}},
+			"released": {{
+				Values:     []string{"2023-01-01", "2023-06-30"},
+				Modifiers:  []Modifier{ModifierNot},
+				Comparator: ComparatorRange,
+			}},
+			"tag": {{
+				Values: []string{"123"},
+			}},
+		},
+	}
+
+	ops := p.Parse("plays:>1000 !released:2023-01-01..2023-06-30 tag:123")
+	if !ops.Equals(&expected) {
+		t.Errorf("Operator is not as expected.\n       Expected: %#v\n       Actual: %#v", expected, ops)
+	}
+
+	ops = p.ParseMap(url.Values{
+		"plays":     []string{">1000"},
+		"!released": []string{"2023-01-01..2023-06-30"},
+		"tag":       []string{"123"},
+	})
+	if !ops.Equals(&expected) {
+		t.Errorf("Operator is not as expected.\n       Expected: %#v\n       Actual: %#v", expected, ops)
+	}
+
+	// Comparators without an operand and values with more than one range are plain values.
+	expected = Operators{
+		Values: map[string][]Operator{
+			"plays":    {{Values: []string{">="}}},
+			"released": {{Values: []string{"1..2..3"}}},
+		},
+	}
+	ops = p.Parse("plays:>= released:1..2..3")
+	if !ops.Equals(&expected) {
+		t.Errorf("Operator is not as expected.\n       Expected: %#v\n       Actual: %#v", expected, ops)
+	}
+}
+
+func TestParser_ParseComparatorsPlainValues(t *testing.T) {
+	config := &ExpressionParserConfig{
+		ParserConfig: ParserConfig{
+			StringStart:    "\"",
+			StringEnd:      "\"",
+			KeyDelimiter:   ":",
+			ComparatorKeys: []string{"plays"},
+		},
+	}
+	p, err := NewParser(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Keys without comparators and quoted values are kept as is.
+	expected := Operators{
+		Values: map[string][]Operator{
+			"plays": {{
+				Values: []string{">1000"},
+			}},
+			"title": {
+				{Values: []string{"Hello..."}},
+				{Values: []string{">_<"}},
+			},
+		},
+		Remainders: []Operator{{
+			Values: []string{"1..2"},
+		}},
+	}
+	ops := p.Parse(`title:"Hello..." title:>_< plays:">1000" 1..2`)
+	if !ops.Equals(&expected) {
+		t.Errorf("Operator is not as expected.\n       Expected: %#v\n       Actual: %#v", expected, ops)
+	}
+
+	expr, err := p.ParseExpression(`title:"Hello..." | plays:">1000"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedExpr := NewOrExpression(
+		NewOperatorExpression("title", Operator{Values: []string{"Hello..."}}),
+		NewOperatorExpression("plays", Operator{Values: []string{">1000"}}),
+	)
+	if !expr.Equals(expectedExpr) {
+		t.Errorf("Expression is not as expected.\n       Expected: %#v\n       Actual: %#v", expectedExpr, expr)
+	}
+}
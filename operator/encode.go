@@ -0,0 +1,185 @@
+package operator
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ParserEncodeDelegate is the inverse of ParserRegexpDelegate. It converts operators back into the string format which
+// the delegate parses.
+type ParserEncodeDelegate interface {
+	// EncodeOperator returns the string representation of the operator. An empty key denotes a remainder.
+	EncodeOperator(key string, op Operator) string
+
+	// EncodeMapKey is the inverse of ParseMapKey. It combines the key with its modifiers.
+	EncodeMapKey(key string, mods []Modifier) string
+}
+
+// DefaultEncoder is the delegate used by Operators.String.
+var DefaultEncoder ParserEncodeDelegate = &ParserConfig{
+	StringStart:  "\"",
+	StringEnd:    "\"",
+	KeyDelimiter: ":",
+	Modifiers:    Modifiers,
+}
+
+// EncodeOperator returns the string representation of the operator. Each value is encoded as a separate term, with the
+// key and modifiers repeated. Values are quoted if required.
+//
+// Values which cannot be parsed back are omitted. These are empty values and, if quoting is required, values
+// containing StringEnd. If no values remain, an empty string is returned.
+func (c *ParserConfig) EncodeOperator(key string, op Operator) string {
+	var prefix string
+	if key != "" {
+		prefix = c.EncodeMapKey(key, op.Modifiers) + c.KeyDelimiter
+	}
+
+	// Plain values of keys with comparators are quoted if they would otherwise be parsed as a comparator.
+	forceQuotes := op.Comparator == ComparatorNone && c.hasComparators(key)
+
+	values := EncodeComparator(op.Comparator, op.Values)
+	terms := make([]string, 0, len(values))
+	for _, v := range values {
+		quote := forceQuotes
+		if quote {
+			comparator, _ := ParseComparator(v)
+			quote = comparator != ComparatorNone
+		}
+		encoded, ok := c.encodeValue(v, quote)
+		if !ok {
+			continue
+		}
+		terms = append(terms, prefix+encoded)
+	}
+	return strings.Join(terms, " ")
+}
+
+// EncodeMapKey combines the key with its modifiers. Modifiers which are not part of the config are dropped, as they
+// cannot be parsed.
+func (c *ParserConfig) EncodeMapKey(key string, mods []Modifier) string {
+	var sb strings.Builder
+	for _, m := range mods {
+		for _, cm := range c.Modifiers {
+			if m == cm {
+				sb.WriteRune(rune(m))
+				break
+			}
+		}
+	}
+	sb.WriteString(key)
+	return sb.String()
+}
+
+// encodeValue quotes the value if it would not otherwise be parsed as a single value, or if quote is true. Returns false
+// if the value cannot be encoded such that it is parsed back as is.
+func (c *ParserConfig) encodeValue(v string, quote bool) (string, bool) {
+	if v == "" {
+		return "", false
+	}
+	if c.StringStart == "" || c.StringEnd == "" {
+		return v, true
+	}
+	needsQuotes := quote ||
+		strings.IndexFunc(v, unicode.IsSpace) > -1 ||
+		strings.Contains(v, c.StringStart) ||
+		strings.Contains(v, c.StringEnd) ||
+		(c.KeyDelimiter != "" && strings.Contains(v, c.KeyDelimiter))
+	if !needsQuotes {
+		return v, true
+	}
+
+	// The quoted value ends at the first StringEnd.
+	if strings.Contains(v, c.StringEnd) {
+		return "", false
+	}
+	return c.StringStart + v + c.StringEnd, true
+}
+
+// Encode converts the operators into a string which can be parsed by the delegate. The output is canonical: keys are
+// sorted, operators are kept in the order they were added, and remainders are placed at the end.
+func (o *Operators) Encode(d ParserEncodeDelegate) string {
+	keys := make([]string, 0, len(o.Values))
+	for k := range o.Values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var terms []string
+	for _, k := range keys {
+		for _, op := range o.Values[k] {
+			if t := d.EncodeOperator(k, op); t != "" {
+				terms = append(terms, t)
+			}
+		}
+	}
+	for _, r := range o.Remainders {
+		if t := d.EncodeOperator("", r); t != "" {
+			terms = append(terms, t)
+		}
+	}
+	return strings.Join(terms, " ")
+}
+
+// String encodes the operators using the DefaultEncoder.
+func (o *Operators) String() string {
+	return o.Encode(DefaultEncoder)
+}
+
+// EncodeValues converts the operators into url.Values which can be parsed by Parser.ParseMap. Modifiers are combined
+// with the key through ParserEncodeDelegate.EncodeMapKey. As ParseMap does not support remainders, they are omitted.
+//
+// url.Values holds a single list of values per key, and ParseMap returns a single operator for each key (and one for
+// each comparator). Thus, operators sharing a key and modifiers are merged: tag:1 tag:2 from Parser.Parse is returned
+// by ParseMap as a single operator with the values 1 and 2. Operators returned by ParseMap are returned as is. Use
+// Encode to keep the operators separate.
+func (o *Operators) EncodeValues(d ParserEncodeDelegate) url.Values {
+	values := url.Values{}
+	for k, ops := range o.Values {
+		for _, op := range ops {
+			key := d.EncodeMapKey(k, op.Modifiers)
+			for _, v := range EncodeComparator(op.Comparator, op.Values) {
+				if v == "" {
+					continue
+				}
+				values[key] = append(values[key], v)
+			}
+		}
+	}
+	return values
+}
+
+// ToValues converts the operators into url.Values using the DefaultEncoder. See EncodeValues.
+func (o *Operators) ToValues() url.Values {
+	return o.EncodeValues(DefaultEncoder)
+}
+
+// Remove removes the first operator under the key which equals the provided operator. If the key is empty, the
+// remainders are searched instead. Returns true if an operator was removed.
+func (o *Operators) Remove(key string, op Operator) bool {
+	if key == "" {
+		for i, r := range o.Remainders {
+			if r.Equals(op) {
+				o.Remainders = append(o.Remainders[:i:i], o.Remainders[i+1:]...)
+				return true
+			}
+		}
+		return false
+	}
+
+	ops := o.Values[key]
+	for i, existing := range ops {
+		if !existing.Equals(op) {
+			continue
+		}
+		ops = append(ops[:i:i], ops[i+1:]...)
+		if len(ops) == 0 {
+			delete(o.Values, key)
+		} else {
+			o.Values[key] = ops
+		}
+		return true
+	}
+	return false
+}
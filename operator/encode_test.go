@@ -0,0 +1,183 @@
+package operator
+
+import (
+	"testing"
+)
+
+func TestOperators_Encode(t *testing.T) {
+	config := &ParserConfig{
+		StringStart:    "\"",
+		StringEnd:      "\"",
+		KeyDelimiter:   ":",
+		ComparatorKeys: []string{"plays", "released"},
+	}
+	p, err := NewParser(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		s        string
+		expected string
+	}{
+		{
+			s:        " tags:\"123454 fjgie\" tag:123456 ",
+			expected: `tag:123456 tags:"123454 fjgie"`,
+		},
+		{
+			s:        "hello !tag:54949 tag:123456 |artist:\"a:b\"",
+			expected: `|artist:"a:b" !tag:54949 tag:123456 hello`,
+		},
+		{
+			s:        "plays:>1000 released:2023-01-01..2023-06-30",
+			expected: "plays:>1000 released:2023-01-01..2023-06-30",
+		},
+	}
+
+	for i, test := range tests {
+		ops := p.Parse(test.s)
+		str := ops.Encode(config)
+		if str != test.expected {
+			t.Errorf("[%d] Expected %s, got %s", i, test.expected, str)
+		}
+
+		// Round trip through both the string and the map representations.
+		reparsed := p.Parse(str)
+		if !reparsed.Equals(&ops) {
+			t.Errorf("[%d] Operators changed after round trip.\n       Expected: %#v\n       Actual: %#v", i, ops, reparsed)
+		}
+
+		mapped := p.ParseMap(ops.ToValues())
+		ops.Remainders = nil
+		if !mapped.Equals(&ops) {
+			t.Errorf("[%d] Operators changed after round trip.\n       Expected: %#v\n       Actual: %#v", i, ops, mapped)
+		}
+	}
+}
+
+func TestOperators_EncodeRoundTrip(t *testing.T) {
+	config := &ParserConfig{
+		StringStart:    "\"",
+		StringEnd:      "\"",
+		KeyDelimiter:   ":",
+		ComparatorKeys: []string{"plays"},
+	}
+	p, err := NewParser(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Repeated keys are kept as separate operators by Encode.
+	ops := p.Parse("tag:1 tag:2 !tag:3")
+	if l := len(ops.Values["tag"]); l != 3 {
+		t.Fatalf("Expecting 3 operators, got %d", l)
+	}
+	if reparsed := p.Parse(ops.Encode(config)); !reparsed.Equals(&ops) {
+		t.Errorf("Operators changed after round trip.\n       Expected: %#v\n       Actual: %#v", ops, reparsed)
+	}
+
+	// ParseMap returns a single operator per key and modifiers, so they are merged.
+	merged := Operators{
+		Values: map[string][]Operator{
+			"tag": {
+				{Values: []string{"1", "2"}},
+				{Values: []string{"3"}, Modifiers: []Modifier{ModifierNot}},
+			},
+		},
+	}
+	if mapped := p.ParseMap(ops.ToValues()); !mapped.Equals(&merged) {
+		t.Errorf("Operators are not as expected.\n       Expected: %#v\n       Actual: %#v", merged, mapped)
+	}
+
+	// Operators from ParseMap are returned as is.
+	if mapped := p.ParseMap(merged.ToValues()); !mapped.Equals(&merged) {
+		t.Errorf("Operators changed after round trip.\n       Expected: %#v\n       Actual: %#v", merged, mapped)
+	}
+
+	// Quoted values, including plain values which look like comparators.
+	quoted := Operators{
+		Values: map[string][]Operator{
+			"plays": {{Values: []string{">1000"}}},
+			"title": {
+				{Values: []string{"a:b"}},
+				{Values: []string{"hello world"}},
+			},
+		},
+	}
+	str := quoted.Encode(config)
+	if expected := `plays:">1000" title:"a:b" title:"hello world"`; str != expected {
+		t.Errorf("Expected %s, got %s", expected, str)
+	}
+	if reparsed := p.Parse(str); !reparsed.Equals(&quoted) {
+		t.Errorf("Operators changed after round trip.\n       Expected: %#v\n       Actual: %#v", quoted, reparsed)
+	}
+
+	// Values which cannot be parsed back are omitted.
+	invalid := Operators{
+		Values: map[string][]Operator{
+			"title": {
+				{Values: []string{""}},
+				{Values: []string{`say "hi"`, "ok"}},
+			},
+		},
+		Remainders: []Operator{{Values: []string{""}}},
+	}
+	if str := invalid.Encode(config); str != "title:ok" {
+		t.Errorf("Expected title:ok, got %s", str)
+	}
+	if values := invalid.ToValues(); len(values["title"]) != 2 {
+		t.Errorf("Expecting the empty value to be omitted, got %v", values)
+	}
+}
+
+func TestOperators_EncodeValuesModifiers(t *testing.T) {
+	config := &ParserConfig{
+		KeyDelimiter: ":",
+		Modifiers:    []Modifier{ModifierNot},
+	}
+	ops := Operators{
+		Values: map[string][]Operator{
+			"tag": {{Values: []string{"1"}, Modifiers: []Modifier{ModifierOr, ModifierNot}}},
+		},
+	}
+
+	// Modifiers are filtered in the same way for both representations.
+	if str := ops.Encode(config); str != "!tag:1" {
+		t.Errorf("Expected !tag:1, got %s", str)
+	}
+	values := ops.EncodeValues(config)
+	if len(values) != 1 || len(values["!tag"]) != 1 {
+		t.Errorf("Expected !tag, got %v", values)
+	}
+}
+
+func TestOperators_Remove(t *testing.T) {
+	p, err := NewParser(ParserConfig{
+		StringStart:  "\"",
+		StringEnd:    "\"",
+		KeyDelimiter: ":",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ops := p.Parse("tag:1 tag:2 hello")
+	if !ops.Remove("tag", Operator{Values: []string{"1"}}) {
+		t.Error("Expecting tag:1 to be removed")
+	}
+	if !ops.Remove("", Operator{Values: []string{"hello"}}) {
+		t.Error("Expecting hello to be removed")
+	}
+	if ops.Remove("tag", Operator{Values: []string{"3"}}) {
+		t.Error("Expecting tag:3 to not be removed")
+	}
+	if str := ops.String(); str != "tag:2" {
+		t.Errorf("Expecting tag:2, got %s", str)
+	}
+	if !ops.Remove("tag", Operator{Values: []string{"2"}}) {
+		t.Error("Expecting tag:2 to be removed")
+	}
+	if _, ok := ops.Values["tag"]; ok {
+		t.Error("Expecting tag key to be removed")
+	}
+}
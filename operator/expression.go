@@ -0,0 +1,144 @@
+package operator
+
+// ExpressionType denotes the type of node within an Expression tree.
+type ExpressionType int
+
+const (
+	// ExpressionOperator is a leaf node which contains a single operator (or remainder if the key is empty).
+	ExpressionOperator ExpressionType = iota
+
+	// ExpressionAnd requires all of its children to be satisfied.
+	ExpressionAnd
+
+	// ExpressionOr requires at least one of its children to be satisfied.
+	ExpressionOr
+
+	// ExpressionNot negates its single child.
+	ExpressionNot
+)
+
+// String returns a human-readable version of the expression type.
+func (t ExpressionType) String() string {
+	switch t {
+	case ExpressionOperator:
+		return "OPERATOR"
+	case ExpressionAnd:
+		return "AND"
+	case ExpressionOr:
+		return "OR"
+	case ExpressionNot:
+		return "NOT"
+	}
+	return "UNKNOWN"
+}
+
+// Expression is a node in a boolean expression tree produced by Parser.ParseExpression. Branch nodes (AND, OR and NOT)
+// contain children, while leaf nodes (OPERATOR) contain a key and the operator itself. A leaf with an empty key is a
+// remainder.
+type Expression struct {
+	// Type of the node.
+	Type ExpressionType
+
+	// Children of the node. AND and OR nodes have at least two children, while NOT nodes have exactly one. Leaf nodes
+	// have none.
+	Children []*Expression
+
+	// Key of the operator. Only applicable to leaf nodes. Empty for remainders.
+	Key string
+
+	// Operator for the leaf node.
+	Operator Operator
+
+	// Position is the byte offset in the input string where this node starts.
+	Position int
+}
+
+// NewOperatorExpression creates a leaf node.
+func NewOperatorExpression(key string, op Operator) *Expression {
+	return &Expression{
+		Type:     ExpressionOperator,
+		Key:      key,
+		Operator: op,
+	}
+}
+
+// NewAndExpression creates an AND node from the provided children.
+func NewAndExpression(children ...*Expression) *Expression {
+	return &Expression{
+		Type:     ExpressionAnd,
+		Children: children,
+	}
+}
+
+// NewOrExpression creates an OR node from the provided children.
+func NewOrExpression(children ...*Expression) *Expression {
+	return &Expression{
+		Type:     ExpressionOr,
+		Children: children,
+	}
+}
+
+// NewNotExpression creates a NOT node negating the provided child.
+func NewNotExpression(child *Expression) *Expression {
+	return &Expression{
+		Type:     ExpressionNot,
+		Children: []*Expression{child},
+	}
+}
+
+// IsRemainder returns true if the node is a leaf without a key.
+func (e *Expression) IsRemainder() bool {
+	return e.Type == ExpressionOperator && e.Key == ""
+}
+
+// Equals returns true if the provided expression is structurally the same as the current one. Positions are ignored.
+func (e *Expression) Equals(b *Expression) bool {
+	if e == nil || b == nil {
+		return e == b
+	}
+	if e.Type != b.Type || len(e.Children) != len(b.Children) {
+		return false
+	}
+	if e.Type == ExpressionOperator {
+		return e.Key == b.Key && e.Operator.Equals(b.Operator)
+	}
+	for i, c := range e.Children {
+		if !c.Equals(b.Children[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// ExpressionWalkFunc is called for every node visited by Walk. Returning false prevents the children of the current
+// node from being visited.
+type ExpressionWalkFunc func(e *Expression, depth int) bool
+
+// Walk visits the expression tree depth-first, in pre-order.
+func (e *Expression) Walk(fn ExpressionWalkFunc) {
+	e.walk(fn, 0)
+}
+
+func (e *Expression) walk(fn ExpressionWalkFunc, depth int) {
+	if e == nil {
+		return
+	}
+	if !fn(e, depth) {
+		return
+	}
+	for _, c := range e.Children {
+		c.walk(fn, depth+1)
+	}
+}
+
+// Leaves returns all leaf nodes in the order they appear in the tree.
+func (e *Expression) Leaves() []*Expression {
+	var xs []*Expression
+	e.Walk(func(n *Expression, _ int) bool {
+		if n.Type == ExpressionOperator {
+			xs = append(xs, n)
+		}
+		return true
+	})
+	return xs
+}
@@ -95,10 +95,17 @@ type Operator struct {
 
 	// Modifiers associated with the operator.
 	Modifiers []Modifier
+
+	// Comparator associated with the operator, if any. For ComparatorRange, Values contains exactly two items: the
+	// start and the end of the range, either of which may be empty.
+	Comparator Comparator
 }
 
 // Equals returns true of the provided operator is the same as the current one.
 func (o *Operator) Equals(b Operator) bool {
+	if o.Comparator != b.Comparator {
+		return false
+	}
 	if len(o.Modifiers) != len(b.Modifiers) {
 		return false
 	}
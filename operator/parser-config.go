@@ -43,6 +43,14 @@ type ParserDelegateSatDefaultModifiers interface {
 	SetDefaultModifiers(m ...Modifier)
 }
 
+// ParserDelegateSetDefaultModifiersIfUnset is an optional interface allowing setting of "default" modifiers only if
+// none have been configured. NewParser prefers it over ParserDelegateSatDefaultModifiers.
+type ParserDelegateSetDefaultModifiersIfUnset interface {
+	// SetDefaultModifiersIfUnset should store the provided modifiers as the modifiers used in the REGEXP and the
+	// ParseMapKey function if no modifiers have been configured.
+	SetDefaultModifiersIfUnset(m ...Modifier)
+}
+
 // ParserConfig implements ParserRegexpDelegate. It looks for operators of the form
 //
 //	[modifier-character][key][key-delimiter][str-start][stuff][str-end]
@@ -69,6 +77,16 @@ type ParserConfig struct {
 	// List of modifier runes.
 	Modifiers []Modifier
 
+	// ComparatorKeys are the keys for which comparator syntax is parsed within values (e.g., plays:>100 or
+	// released:2020..2022). See ParseComparator. Values of other keys, remainders and quoted values are kept as is, so
+	// that title:"Hello..." or title:>_< remain plain values. When parsing with a Schema, the schema determines the keys
+	// instead.
+	ComparatorKeys []string
+
+	// KeyCharacters are additional characters allowed in keys. By default, keys may only contain word characters and
+	// dashes. For example, set to "." to allow for keys such as metadata.label.
+	KeyCharacters string
+
 	// Cache for the keyRegexp, so it only has to be generated once.
 	keyRegexp *regexp.Regexp
 
@@ -80,8 +98,17 @@ func (c *ParserConfig) SetDefaultModifiers(m ...Modifier) {
 	c.Modifiers = m
 }
 
+// SetDefaultModifiersIfUnset implements ParserDelegateSetDefaultModifiersIfUnset. The modifiers are only set if none
+// have been configured. To disable modifiers, set Modifiers to an empty (non-nil) slice.
+func (c *ParserConfig) SetDefaultModifiersIfUnset(m ...Modifier) {
+	if c.Modifiers != nil {
+		return
+	}
+	c.Modifiers = m
+}
+
 func (c *ParserConfig) regexpKeyString() string {
-	return fmt.Sprintf("([%s]*)([\\w-]+)", regexp.QuoteMeta(string(c.Modifiers)))
+	return fmt.Sprintf("([%s]*)([\\w%s-]+)", regexp.QuoteMeta(string(c.Modifiers)), regexp.QuoteMeta(c.KeyCharacters))
 }
 
 func (c *ParserConfig) regexpString() string {
@@ -182,6 +209,33 @@ func (c *ParserConfig) parseMatchNoMods(match []string) (mods []Modifier, key st
 	return
 }
 
+// ParseValue implements ParserDelegateComparators. Comparators are only extracted for unquoted values of the keys in
+// ComparatorKeys.
+func (c *ParserConfig) ParseValue(key, value string, quoted bool) (Comparator, []string) {
+	if quoted || !c.hasComparators(key) {
+		return ComparatorNone, []string{value}
+	}
+	return ParseComparator(value)
+}
+
+// IsQuoted implements ParserDelegateComparators. Based on the regexp string, the last submatch is the value without the
+// string start and string end, which is only set if the value was quoted.
+func (c *ParserConfig) IsQuoted(match []string) bool {
+	return c.StringStart != "" && len(match) > 0 && match[len(match)-1] != ""
+}
+
+func (c *ParserConfig) hasComparators(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, k := range c.ComparatorKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
 // ParseMapKey decodes the input key by splitting out its modifiers.This is used for the Parser.ParseMap
 // functionality.
 func (c *ParserConfig) ParseMapKey(inputKey string) (mods []Modifier, key string, err error) {
@@ -1,6 +1,18 @@
 package operator
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrSchemaUnknownKey           = errors.New("unknown key")
+	ErrSchemaInvalidValue         = errors.New("invalid value")
+	ErrSchemaModifierNotAllowed   = errors.New("modifier not allowed")
+	ErrSchemaComparatorNotAllowed = errors.New("comparator not allowed")
+	ErrSchemaTooManyValues        = errors.New("too many values")
+)
 
 // ParseMapKeyError is an error related to parsing of a map key. It wraps the error returned from
 // ParserRegexpDelegate.ParseMapKey
@@ -34,11 +46,76 @@ type ParseMatchError struct {
 	// Matches that were returned, if any.
 	Matches []string
 
+	// Position (byte offset) in the input string of the match.
+	Position int
+
 	// The error in question
 	Base error
 }
 
 // Error string
 func (e *ParseMatchError) Error() string {
-	return fmt.Sprintf("Could not parse provided match. %s", e.Base)
-}
\ No newline at end of file
+	return fmt.Sprintf("Could not parse provided match at position %d. %s", e.Position, e.Base)
+}
+
+// ParseExpressionError is an error related to the parsing of an expression through
+// ParserExpressionDelegate.ParseExpression.
+type ParseExpressionError struct {
+	// The error in question
+	Base error
+
+	// Position (byte offset) in the input string where the error occurred.
+	Position int
+}
+
+// Error string
+func (e *ParseExpressionError) Error() string {
+	return fmt.Sprintf("Could not parse expression at position %d. %s", e.Position, e.Base)
+}
+
+// Unwrap returns the base error.
+func (e *ParseExpressionError) Unwrap() error {
+	return e.Base
+}
+
+// SchemaError occurs when an operator does not satisfy the Schema provided to Parser.ParseWithSchema or
+// Parser.ParseMapWithSchema. The Base error is one of the ErrSchema errors.
+type SchemaError struct {
+	// The error in question
+	Base error
+
+	// Key as provided in the input (i.e., before alias resolution).
+	Key string
+
+	// Value (or modifier / comparator) which caused the error, if applicable.
+	Value string
+
+	// Position (byte offset) in the input string of the operator. -1 if not applicable (e.g., for ParseMap).
+	Position int
+
+	// Suggestion is the closest valid key or enum value, if any.
+	Suggestion string
+}
+
+// Error string
+func (e *SchemaError) Error() string {
+	var sb strings.Builder
+	if e.Value != "" {
+		fmt.Fprintf(&sb, "Value '%s' for key '%s'", e.Value, e.Key)
+	} else {
+		fmt.Fprintf(&sb, "Key '%s'", e.Key)
+	}
+	if e.Position >= 0 {
+		fmt.Fprintf(&sb, " at position %d", e.Position)
+	}
+	fmt.Fprintf(&sb, " has been ignored. %s.", e.Base)
+	if e.Suggestion != "" {
+		fmt.Fprintf(&sb, " Did you mean '%s'?", e.Suggestion)
+	}
+	return sb.String()
+}
+
+// Unwrap returns the base error.
+func (e *SchemaError) Unwrap() error {
+	return e.Base
+}
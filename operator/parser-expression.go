@@ -0,0 +1,398 @@
+package operator
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	ErrExpressionUnexpectedToken    = errors.New("unexpected token")
+	ErrExpressionUnbalancedGroup    = errors.New("unbalanced group")
+	ErrExpressionUnterminatedString = errors.New("unterminated string")
+	ErrExpressionMissingOperand     = errors.New("missing operand")
+	ErrExpressionMissingValue       = errors.New("missing value")
+)
+
+// Default group delimiters for ExpressionParserConfig.
+const (
+	DefaultGroupStart = "("
+	DefaultGroupEnd   = ")"
+)
+
+// ParserExpressionDelegate is an interface that allows the Parser to produce a boolean expression tree through
+// Parser.ParseExpression instead of the flat Operators structure.
+type ParserExpressionDelegate interface {
+	// ParseExpression parses the provided string into an expression tree. An empty string should return a nil
+	// expression with no error.
+	ParseExpression(str string) (*Expression, error)
+}
+
+// ExpressionParserConfig implements ParserExpressionDelegate. Terms are parsed using the same format as ParserConfig
+//
+//	[key][key-delimiter][str-start][stuff][str-end]
+//
+// and can be combined with the following (in order of precedence, highest first):
+//
+//	(...)        grouping
+//	!term        NOT
+//	a & b, a b   AND (juxtaposition is an implicit AND)
+//	a | b        OR
+//
+// The NOT, AND and OR characters are ModifierNot, ModifierAnd and ModifierOr, and are only recognized if they are
+// included in ParserConfig.Modifiers. Unquoted terms end at whitespace, a group character, or an AND / OR character, so
+// a|b is equivalent to a | b. Quote values which contain these characters.
+//
+// For example, with StringStart and StringEnd set to " and KeyDelimiter set to :
+//
+//	(artist:foo | artist:"bar baz") !genre:rock
+//
+// If Keywords is true, the upper case words AND, OR and NOT can also be used as operators.
+//
+// As ParserConfig is embedded, ExpressionParserConfig also satisfies ParserRegexpDelegate so that Parser.Parse and
+// Parser.ParseMap continue to work with the same configuration.
+type ExpressionParserConfig struct {
+	ParserConfig
+
+	// Group start and end characters. Default to DefaultGroupStart and DefaultGroupEnd if not provided.
+	GroupStart string
+	GroupEnd   string
+
+	// Keywords allows for AND, OR and NOT to be used in place of their modifier characters.
+	Keywords bool
+}
+
+func (c *ExpressionParserConfig) groupStart() string {
+	if c.GroupStart == "" {
+		return DefaultGroupStart
+	}
+	return c.GroupStart
+}
+
+func (c *ExpressionParserConfig) groupEnd() string {
+	if c.GroupEnd == "" {
+		return DefaultGroupEnd
+	}
+	return c.GroupEnd
+}
+
+// ParseExpression parses the provided string into an expression tree.
+func (c *ExpressionParserConfig) ParseExpression(str string) (*Expression, error) {
+	tokens, err := c.tokenize(str)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	p := &expressionParser{tokens: tokens}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := p.peek(); ok {
+		if t.typ == expressionTokenGroupEnd {
+			return nil, &ParseExpressionError{Base: ErrExpressionUnbalancedGroup, Position: t.pos}
+		}
+		return nil, &ParseExpressionError{Base: ErrExpressionUnexpectedToken, Position: t.pos}
+	}
+	return e, nil
+}
+
+type expressionTokenType int
+
+const (
+	expressionTokenTerm expressionTokenType = iota
+	expressionTokenAnd
+	expressionTokenOr
+	expressionTokenNot
+	expressionTokenGroupStart
+	expressionTokenGroupEnd
+)
+
+type expressionToken struct {
+	typ   expressionTokenType
+	pos   int
+	end   int
+	key   string
+	value string
+
+	// quoted is true if the value was enclosed in the string start and string end.
+	quoted bool
+
+	// Comparator and values extracted from value through ParserConfig.ParseValue.
+	comparator Comparator
+	values     []string
+}
+
+// tokenize splits the input string into tokens.
+func (c *ExpressionParserConfig) tokenize(str string) ([]expressionToken, error) {
+	var tokens []expressionToken
+
+	i := 0
+	for i < len(str) {
+		r, size := utf8.DecodeRuneInString(str[i:])
+		if unicode.IsSpace(r) {
+			i += size
+			continue
+		}
+
+		rest := str[i:]
+		if typ, n, ok := c.operator(rest); ok {
+			tokens = append(tokens, expressionToken{typ: typ, pos: i, end: i + n})
+			i += n
+			continue
+		}
+
+		t, n, err := c.term(rest)
+		if err != nil {
+			return nil, &ParseExpressionError{Base: err, Position: i}
+		}
+		t.pos, t.end = i, i+n
+		t.comparator, t.values = c.ParseValue(t.key, t.value, t.quoted)
+		tokens = append(tokens, t)
+		i += n
+	}
+	return tokens, nil
+}
+
+// operator checks if the string starts with a group character, a modifier or a keyword, returning the token type and
+// the number of bytes consumed.
+func (c *ExpressionParserConfig) operator(str string) (expressionTokenType, int, bool) {
+	switch {
+	case strings.HasPrefix(str, c.groupStart()):
+		return expressionTokenGroupStart, len(c.groupStart()), true
+	case strings.HasPrefix(str, c.groupEnd()):
+		return expressionTokenGroupEnd, len(c.groupEnd()), true
+	case c.isModifier(ModifierNot, str[0]):
+		return expressionTokenNot, 1, true
+	case c.isModifier(ModifierOr, str[0]):
+		return expressionTokenOr, 1, true
+	case c.isModifier(ModifierAnd, str[0]):
+		return expressionTokenAnd, 1, true
+	}
+	if c.Keywords {
+		return c.keyword(str)
+	}
+	return 0, 0, false
+}
+
+// isModifier returns true if the byte is the modifier and the modifier is enabled in the configuration.
+func (c *ExpressionParserConfig) isModifier(m Modifier, b byte) bool {
+	if !m.Matches(b) {
+		return false
+	}
+	for _, mod := range c.Modifiers {
+		if mod == m {
+			return true
+		}
+	}
+	return false
+}
+
+// keyword checks if the string starts with one of the keywords, followed by a word boundary.
+func (c *ExpressionParserConfig) keyword(str string) (expressionTokenType, int, bool) {
+	for kw, typ := range map[string]expressionTokenType{
+		"AND": expressionTokenAnd,
+		"OR":  expressionTokenOr,
+		"NOT": expressionTokenNot,
+	} {
+		if !strings.HasPrefix(str, kw) {
+			continue
+		}
+		if len(str) == len(kw) || c.isTermBoundary(str[len(kw):]) {
+			return typ, len(kw), true
+		}
+	}
+	return 0, 0, false
+}
+
+// isTermBoundary returns true if the string starts with a character that ends an unquoted term. NOT is only recognized
+// at the start of a term, so it is not a boundary.
+func (c *ExpressionParserConfig) isTermBoundary(str string) bool {
+	r, _ := utf8.DecodeRuneInString(str)
+	return unicode.IsSpace(r) ||
+		strings.HasPrefix(str, c.groupStart()) ||
+		strings.HasPrefix(str, c.groupEnd()) ||
+		c.isModifier(ModifierOr, str[0]) ||
+		c.isModifier(ModifierAnd, str[0])
+}
+
+// term reads a single term (key and value) from the start of the string, returning the number of bytes consumed.
+func (c *ExpressionParserConfig) term(str string) (expressionToken, int, error) {
+	t := expressionToken{typ: expressionTokenTerm}
+
+	// The key follows the same rules as ParserConfig ([\w-]+ and KeyCharacters), and must be followed by the key
+	// delimiter.
+	i := 0
+	if c.KeyDelimiter != "" {
+		for i < len(str) && c.isKeyChar(str[i]) {
+			i++
+		}
+		if i > 0 && strings.HasPrefix(str[i:], c.KeyDelimiter) {
+			t.key = str[:i]
+			i += len(c.KeyDelimiter)
+		} else {
+			i = 0
+		}
+	}
+
+	// Quoted value.
+	if c.StringStart != "" && strings.HasPrefix(str[i:], c.StringStart) {
+		start := i + len(c.StringStart)
+		end := strings.Index(str[start:], c.StringEnd)
+		if c.StringEnd == "" || end < 0 {
+			return t, 0, ErrExpressionUnterminatedString
+		}
+		t.value = str[start : start+end]
+		t.quoted = true
+		return t, start + end + len(c.StringEnd), nil
+	}
+
+	// Unquoted value.
+	start := i
+	for i < len(str) && !c.isTermBoundary(str[i:]) {
+		_, size := utf8.DecodeRuneInString(str[i:])
+		i += size
+	}
+	if i == start {
+		return t, 0, ErrExpressionMissingValue
+	}
+	t.value = str[start:i]
+	return t, i, nil
+}
+
+func (c *ExpressionParserConfig) isKeyChar(b byte) bool {
+	return b == '_' || b == '-' ||
+		('a' <= b && b <= 'z') ||
+		('A' <= b && b <= 'Z') ||
+		('0' <= b && b <= '9') ||
+		strings.IndexByte(c.KeyCharacters, b) > -1
+}
+
+// expressionParser is a recursive descent parser that converts a list of tokens into an expression tree.
+type expressionParser struct {
+	tokens []expressionToken
+	idx    int
+}
+
+func (p *expressionParser) peek() (expressionToken, bool) {
+	if p.idx >= len(p.tokens) {
+		return expressionToken{}, false
+	}
+	return p.tokens[p.idx], true
+}
+
+// end returns the position at the end of the last token, used for errors at the end of the input.
+func (p *expressionParser) end() int {
+	if len(p.tokens) == 0 {
+		return 0
+	}
+	return p.tokens[len(p.tokens)-1].end
+}
+
+// parseOr parses: and ('|' and)*
+func (p *expressionParser) parseOr() (*Expression, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	children := []*Expression{first}
+	for {
+		t, ok := p.peek()
+		if !ok || t.typ != expressionTokenOr {
+			break
+		}
+		p.idx++
+		next, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, next)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	e := NewOrExpression(children...)
+	e.Position = first.Position
+	return e, nil
+}
+
+// parseAnd parses: not (('&')? not)*
+func (p *expressionParser) parseAnd() (*Expression, error) {
+	first, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	children := []*Expression{first}
+	for {
+		t, ok := p.peek()
+		if !ok || t.typ == expressionTokenOr || t.typ == expressionTokenGroupEnd {
+			break
+		}
+		if t.typ == expressionTokenAnd {
+			p.idx++
+		}
+		next, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, next)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	e := NewAndExpression(children...)
+	e.Position = first.Position
+	return e, nil
+}
+
+// parseNot parses: '!' not | primary
+func (p *expressionParser) parseNot() (*Expression, error) {
+	t, ok := p.peek()
+	if !ok || t.typ != expressionTokenNot {
+		return p.parsePrimary()
+	}
+	p.idx++
+	child, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	e := NewNotExpression(child)
+	e.Position = t.pos
+	return e, nil
+}
+
+// parsePrimary parses: '(' or ')' | term
+func (p *expressionParser) parsePrimary() (*Expression, error) {
+	t, ok := p.peek()
+	if !ok {
+		return nil, &ParseExpressionError{Base: ErrExpressionMissingOperand, Position: p.end()}
+	}
+	switch t.typ {
+	case expressionTokenTerm:
+		p.idx++
+		e := NewOperatorExpression(t.key, Operator{
+			Values:     t.values,
+			Comparator: t.comparator,
+		})
+		e.Position = t.pos
+		return e, nil
+	case expressionTokenGroupStart:
+		p.idx++
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		end, ok := p.peek()
+		if !ok || end.typ != expressionTokenGroupEnd {
+			return nil, &ParseExpressionError{Base: ErrExpressionUnbalancedGroup, Position: t.pos}
+		}
+		p.idx++
+		return e, nil
+	case expressionTokenGroupEnd:
+		return nil, &ParseExpressionError{Base: ErrExpressionMissingOperand, Position: t.pos}
+	}
+	return nil, &ParseExpressionError{Base: ErrExpressionMissingOperand, Position: t.pos}
+}
@@ -0,0 +1,157 @@
+package operator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParser_ParseExpression(t *testing.T) {
+	p, err := NewParser(&ExpressionParserConfig{
+		ParserConfig: ParserConfig{
+			StringStart:  "\"",
+			StringEnd:    "\"",
+			KeyDelimiter: ":",
+		},
+		Keywords: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	op := func(key, value string) *Expression {
+		return NewOperatorExpression(key, Operator{Values: []string{value}})
+	}
+
+	tests := []struct {
+		s    string
+		expr *Expression
+	}{
+		{
+			s:    "",
+			expr: nil,
+		},
+		{
+			s:    "artist:foo",
+			expr: op("artist", "foo"),
+		},
+		{
+			s: `(artist:foo | artist:"bar baz") !genre:rock`,
+			expr: NewAndExpression(
+				NewOrExpression(op("artist", "foo"), op("artist", "bar baz")),
+				NewNotExpression(op("genre", "rock")),
+			),
+		},
+		{
+			s: "a | b & c",
+			expr: NewOrExpression(
+				op("", "a"),
+				NewAndExpression(op("", "b"), op("", "c")),
+			),
+		},
+		{
+			s: "NOT (a OR b) AND c",
+			expr: NewAndExpression(
+				NewNotExpression(NewOrExpression(op("", "a"), op("", "b"))),
+				op("", "c"),
+			),
+		},
+		{
+			s:    "!!tag:123",
+			expr: NewNotExpression(NewNotExpression(op("tag", "123"))),
+		},
+		{
+			s:    "a|b&tag:c",
+			expr: NewOrExpression(op("", "a"), NewAndExpression(op("", "b"), op("tag", "c"))),
+		},
+		{
+			s:    `tag:"a|b" wow!`,
+			expr: NewAndExpression(op("tag", "a|b"), op("", "wow!")),
+		},
+	}
+
+	for i, test := range tests {
+		expr, err := p.ParseExpression(test.s)
+		if err != nil {
+			t.Errorf("[%d] Unexpected error: %s", i, err)
+			continue
+		}
+		if !expr.Equals(test.expr) {
+			t.Errorf("[%d] Expression is not as expected.\n       Expected: %#v\n       Actual: %#v", i, test.expr, expr)
+		}
+	}
+}
+
+func TestParser_ParseExpressionErrors(t *testing.T) {
+	p, err := NewParser(&ExpressionParserConfig{
+		ParserConfig: ParserConfig{
+			StringStart:  "\"",
+			StringEnd:    "\"",
+			KeyDelimiter: ":",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		s        string
+		err      error
+		position int
+	}{
+		{s: "(a | b", err: ErrExpressionUnbalancedGroup, position: 0},
+		{s: "a | b)", err: ErrExpressionUnbalancedGroup, position: 5},
+		{s: "a |", err: ErrExpressionMissingOperand, position: 3},
+		{s: "a & !", err: ErrExpressionMissingOperand, position: 5},
+		{s: `a tag:"abc`, err: ErrExpressionUnterminatedString, position: 2},
+		{s: "a ()", err: ErrExpressionMissingOperand, position: 3},
+	}
+
+	for i, test := range tests {
+		_, err := p.ParseExpression(test.s)
+		if !errors.Is(err, test.err) {
+			t.Errorf("[%d] Expecting error %s, got %v", i, test.err, err)
+			continue
+		}
+		var perr *ParseExpressionError
+		if !errors.As(err, &perr) {
+			t.Errorf("[%d] Expecting ParseExpressionError", i)
+			continue
+		}
+		if perr.Position != test.position {
+			t.Errorf("[%d] Expecting position %d, got %d", i, test.position, perr.Position)
+		}
+	}
+
+	// Regexp-only delegates cannot parse expressions.
+	p, err = NewParser(ParserConfig{KeyDelimiter: ":"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.ParseExpression("a"); err != ErrInvalidDelegate {
+		t.Errorf("Expecting ErrInvalidDelegate, got %v", err)
+	}
+}
+
+func TestParser_ParseExpressionModifiers(t *testing.T) {
+	// Only NOT is enabled, so | and & are part of the terms.
+	p, err := NewParser(&ExpressionParserConfig{
+		ParserConfig: ParserConfig{
+			KeyDelimiter: ":",
+			Modifiers:    []Modifier{ModifierNot},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expr, err := p.ParseExpression("!a|b c&d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := NewAndExpression(
+		NewNotExpression(NewOperatorExpression("", Operator{Values: []string{"a|b"}})),
+		NewOperatorExpression("", Operator{Values: []string{"c&d"}}),
+	)
+	if !expr.Equals(expected) {
+		t.Errorf("Expression is not as expected.\n       Expected: %#v\n       Actual: %#v", expected, expr)
+	}
+}
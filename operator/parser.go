@@ -3,24 +3,27 @@ package operator
 import (
 	"errors"
 	"strings"
+	"unicode"
 )
 
 var (
 	ErrInvalidDelegate = errors.New("invalid delegate")
 )
 
-// Parser parses maps/strings into operators using the provided delegate to modify its behaviour. Currently, the only
-// delegate possible is the Regexp delegate. This delegate will ParseMatch based on a Regexp returned by the delegate
-// and a corresponding ParseMatch function.
+// Parser parses maps/strings into operators using the provided delegate to modify its behaviour. The base delegate is
+// the Regexp delegate. This delegate will ParseMatch based on a Regexp returned by the delegate and a corresponding
+// ParseMatch function.
+//
+// If the delegate also implements ParserExpressionDelegate (e.g., ExpressionParserConfig), ParseExpression can be used
+// to produce a boolean expression tree supporting grouping and precedence.
 type Parser struct {
-	// Delegate modifies the operation of the parser.
-	//
-	// TODO: Currently, only a Regexp parser is supported, but in the future, this should be extracted to extended to
-	//   allow for other types of parsers.
+	// Delegate modifies the operation of the parser. It is used by Parse and ParseMap. If it also implements
+	// ParserExpressionDelegate, it is used by ParseExpression.
 	Delegate ParserRegexpDelegate
 }
 
-// NewParser generates a new parser based on the provided delegate. If defined, it will also set default modifiers.
+// NewParser generates a new parser based on the provided delegate. If defined, it will also set default modifiers. A
+// delegate implementing ParserDelegateSetDefaultModifiersIfUnset keeps its configured modifiers.
 //
 // Unfortunately, the delegate does not work because we used to take in ParserConfig (note: missing the pointer).
 // However, it currently wants &ParserConfig. Thus, we use interface{}.
@@ -35,7 +38,10 @@ func NewParser(delegate interface{}) (*Parser, error) {
 		return nil, ErrInvalidDelegate
 	}
 
-	if v, ok := d.(ParserDelegateSatDefaultModifiers); ok {
+	switch v := d.(type) {
+	case ParserDelegateSetDefaultModifiersIfUnset:
+		v.SetDefaultModifiersIfUnset(Modifiers...)
+	case ParserDelegateSatDefaultModifiers:
 		v.SetDefaultModifiers(Modifiers...)
 	}
 
@@ -48,6 +54,17 @@ func NewParser(delegate interface{}) (*Parser, error) {
 // as operators. In this implementation, any query string parameters that do not have a key are ignored. Thus, no
 // "remainders" will be returned.
 func (p *Parser) ParseMap(m map[string][]string) Operators {
+	return p.parseMap(m, nil)
+}
+
+// ParseMapWithSchema parses a map into operators in the same way as ParseMap. Any operator which does not satisfy the
+// schema is excluded, and a SchemaError is added to Operators.Errors. As there is no input string, the position of
+// each error is -1.
+func (p *Parser) ParseMapWithSchema(m map[string][]string, schema *Schema) Operators {
+	return p.parseMap(m, schema)
+}
+
+func (p *Parser) parseMap(m map[string][]string, schema *Schema) Operators {
 	operators := Operators{
 		Values:     make(map[string][]Operator),
 		Remainders: make([]Operator, 0, 10),
@@ -71,16 +88,89 @@ func (p *Parser) ParseMap(m map[string][]string) Operators {
 			})
 			continue
 		}
-		operators.AddOperator(key, Operator{
+		p.addOperatorValues(&operators, schema, -1, key, mods, vv, false)
+	}
+	return operators
+}
+
+// addOperatorValues adds the values as an operator. If the delegate supports comparators, any value containing a
+// comparator is added as its own operator. If a schema is provided, each operator is validated before it is added.
+func (p *Parser) addOperatorValues(
+	operators *Operators,
+	schema *Schema,
+	position int,
+	key string,
+	mods []Modifier,
+	vv []string,
+	quoted bool,
+) {
+	for _, op := range p.operatorsFromValues(schema, key, mods, vv, quoted) {
+		if len(op.Values) == 0 {
+			continue
+		}
+		opKey := key
+		if schema != nil {
+			var err error
+			opKey, op, err = schema.Validate(operators, key, op, position)
+			if err != nil {
+				operators.Errors = append(operators.Errors, err)
+				continue
+			}
+		}
+		operators.AddOperator(opKey, op)
+	}
+}
+
+// operatorsFromValues splits the values into operators by comparator. If a schema is provided, it determines the keys
+// for which comparators are parsed instead of the delegate.
+func (p *Parser) operatorsFromValues(schema *Schema, key string, mods []Modifier, vv []string, quoted bool) []Operator {
+	d, ok := p.Delegate.(ParserDelegateComparators)
+	if !ok {
+		return []Operator{{
 			Values:    vv,
 			Modifiers: mods,
+		}}
+	}
+	parseValue := d.ParseValue
+	if schema != nil {
+		parseValue = schema.parseValue
+	}
+
+	var ops []Operator
+	plain := make([]string, 0, len(vv))
+	for _, v := range vv {
+		comparator, values := parseValue(key, v, quoted)
+		if comparator == ComparatorNone {
+			plain = append(plain, values...)
+			continue
+		}
+		ops = append(ops, Operator{
+			Values:     values,
+			Modifiers:  mods,
+			Comparator: comparator,
 		})
 	}
-	return operators
+	return append(ops, Operator{
+		Values:    plain,
+		Modifiers: mods,
+	})
 }
 
 // Parse parses a string into operators based on the regexp generated from the parser config.
 func (p *Parser) Parse(str string) Operators {
+	return p.parse(str, nil)
+}
+
+// ParseWithSchema parses a string into operators in the same way as Parse. Any operator which does not satisfy the
+// schema is excluded, and a SchemaError is added to Operators.Errors. Unlike Parse, errors returned by
+// ParserRegexpDelegate.ParseMatch are also added to Operators.Errors as a ParseMatchError.
+func (p *Parser) ParseWithSchema(str string, schema *Schema) Operators {
+	return p.parse(str, schema)
+}
+
+func (p *Parser) parse(str string, schema *Schema) Operators {
+	// Positions are reported relative to the original string.
+	offset := len(str) - len(strings.TrimLeftFunc(str, unicode.IsSpace))
 	str = strings.TrimSpace(str)
 
 	operators := Operators{
@@ -96,16 +186,26 @@ func (p *Parser) Parse(str string) Operators {
 	}
 
 	// Get all matches
-	matches := r.FindAllStringSubmatch(str, -1)
+	matches := r.FindAllStringSubmatchIndex(str, -1)
 	if matches == nil {
 		return operators
 	}
 
-	for _, m := range matches {
+	for _, idx := range matches {
+		m := submatches(str, idx)
+		position := offset + idx[0]
+
 		mods, key, value, err := p.Delegate.ParseMatch(m)
 		if err != nil {
 			// On any error, it should continue. Note that this is for backwards compatibility. The function signature
 			// does not allow for parser errors. In the case of parser errors, the whole match should be ignored.
+			if schema != nil {
+				operators.Errors = append(operators.Errors, &ParseMatchError{
+					Matches:  m,
+					Position: position,
+					Base:     err,
+				})
+			}
 			continue
 		}
 
@@ -120,12 +220,35 @@ func (p *Parser) Parse(str string) Operators {
 			continue
 		}
 
-		// Otherwise, we add the operator
-		operators.AddOperator(key, Operator{
-			Values:    value,
-			Modifiers: mods,
-		})
+		// Otherwise, we add the operator. Quoted values are never parsed for comparators.
+		var quoted bool
+		if d, ok := p.Delegate.(ParserDelegateComparators); ok {
+			quoted = d.IsQuoted(m)
+		}
+		p.addOperatorValues(&operators, schema, position, key, mods, value, quoted)
 	}
 
 	return operators
 }
+
+// submatches converts the result of regexp.FindStringSubmatchIndex into the result of regexp.FindStringSubmatch.
+func submatches(str string, idx []int) []string {
+	m := make([]string, len(idx)/2)
+	for i := range m {
+		if idx[2*i] < 0 {
+			continue
+		}
+		m[i] = str[idx[2*i]:idx[2*i+1]]
+	}
+	return m
+}
+
+// ParseExpression parses a string into a boolean expression tree. The delegate must implement
+// ParserExpressionDelegate, otherwise ErrInvalidDelegate is returned. An empty string results in a nil expression.
+func (p *Parser) ParseExpression(str string) (*Expression, error) {
+	d, ok := p.Delegate.(ParserExpressionDelegate)
+	if !ok {
+		return nil, ErrInvalidDelegate
+	}
+	return d.ParseExpression(str)
+}
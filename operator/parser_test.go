@@ -158,3 +158,25 @@ func TestParser_ParseMap(t *testing.T) {
 		}
 	}
 }
+
+func TestParserConfig_SetDefaultModifiers(t *testing.T) {
+	c := &ParserConfig{Modifiers: []Modifier{ModifierNot}}
+	c.SetDefaultModifiers(Modifiers...)
+	if len(c.Modifiers) != len(Modifiers) {
+		t.Errorf("Expecting SetDefaultModifiers to reset the modifiers, got %v", c.Modifiers)
+	}
+
+	c = &ParserConfig{Modifiers: []Modifier{ModifierNot}}
+	c.SetDefaultModifiersIfUnset(Modifiers...)
+	if len(c.Modifiers) != 1 || c.Modifiers[0] != ModifierNot {
+		t.Errorf("Expecting SetDefaultModifiersIfUnset to keep the modifiers, got %v", c.Modifiers)
+	}
+
+	c = &ParserConfig{}
+	if _, err := NewParser(c); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Modifiers) != len(Modifiers) {
+		t.Errorf("Expecting NewParser to set the default modifiers, got %v", c.Modifiers)
+	}
+}
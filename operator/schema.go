@@ -0,0 +1,303 @@
+package operator
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	strutil "github.com/monstercat/golib/string"
+)
+
+// ValueType defines the type of value a key in the Schema accepts.
+type ValueType string
+
+const (
+	ValueTypeString ValueType = ""
+	ValueTypeUUID   ValueType = "uuid"
+	ValueTypeInt    ValueType = "int"
+	ValueTypeBool   ValueType = "bool"
+	ValueTypeDate   ValueType = "date"
+	ValueTypeEnum   ValueType = "enum"
+)
+
+// SchemaDateLayouts are the layouts accepted for ValueTypeDate.
+var SchemaDateLayouts = []string{
+	"2006-01-02",
+	time.RFC3339,
+}
+
+// SchemaKey defines a single key allowed by the Schema.
+type SchemaKey struct {
+	// Key is the canonical key. Operators parsed with an alias are stored under this key.
+	Key string
+
+	// Aliases are alternative keys which resolve to Key.
+	Aliases []string
+
+	// Type of the values. Defaults to ValueTypeString, which accepts anything.
+	Type ValueType
+
+	// Enum contains the allowed values for ValueTypeEnum. Values are matched case-insensitively and are replaced with
+	// the value defined here.
+	Enum []string
+
+	// Modifiers that are allowed for the key. If nil, all modifiers are allowed.
+	Modifiers []Modifier
+
+	// MaxValues is the maximum number of values allowed for the key, across all operators. Ranges count as a single
+	// value. Zero means unlimited.
+	MaxValues int
+}
+
+// allowsModifier returns true if the modifier is allowed for the key.
+func (k *SchemaKey) allowsModifier(m Modifier) bool {
+	if k.Modifiers == nil {
+		return true
+	}
+	for _, mod := range k.Modifiers {
+		if mod == m {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsComparator returns true if the comparator is valid for the type. Only ordered types support comparisons.
+func (k *SchemaKey) allowsComparator(c Comparator) bool {
+	switch c {
+	case ComparatorNone, ComparatorEq:
+		return true
+	}
+	return k.comparable()
+}
+
+// comparable returns true if the type is ordered, i.e., it supports comparators.
+func (k *SchemaKey) comparable() bool {
+	return k.Type == ValueTypeInt || k.Type == ValueTypeDate
+}
+
+// normalizeValue validates the value against the type of the key, returning the value to store.
+func (k *SchemaKey) normalizeValue(v string) (string, bool) {
+	switch k.Type {
+	case ValueTypeUUID:
+		return v, strutil.IsUuid(v)
+	case ValueTypeInt:
+		_, err := strconv.ParseInt(v, 10, 64)
+		return v, err == nil
+	case ValueTypeBool:
+		switch strings.ToLower(v) {
+		case "t", "true", "1", "f", "false", "0":
+			return v, true
+		}
+		return v, false
+	case ValueTypeDate:
+		for _, l := range SchemaDateLayouts {
+			if _, err := time.Parse(l, v); err == nil {
+				return v, true
+			}
+		}
+		return v, false
+	case ValueTypeEnum:
+		for _, e := range k.Enum {
+			if strings.EqualFold(e, v) {
+				return e, true
+			}
+		}
+		return v, false
+	}
+	return v, true
+}
+
+// Schema defines the keys that are allowed when parsing through Parser.ParseWithSchema or Parser.ParseMapWithSchema.
+// Operators which do not satisfy the schema are excluded and a SchemaError is added to Operators.Errors.
+//
+// If the delegate supports comparators (see ParserDelegateComparators), the schema determines which keys they are
+// parsed for: only keys of ValueTypeInt and ValueTypeDate.
+//
+// A Schema can be created through NewSchema or as a literal. NewSchema indexes the keys for faster lookups, in which
+// case Keys should not be modified afterwards.
+type Schema struct {
+	// Keys allowed by the schema.
+	Keys []SchemaKey
+
+	// Lookup of key and alias to the index in Keys. Only set by NewSchema.
+	lookup map[string]int
+}
+
+// NewSchema creates a schema with the provided keys.
+func NewSchema(keys ...SchemaKey) *Schema {
+	s := &Schema{
+		Keys:   keys,
+		lookup: make(map[string]int),
+	}
+	for i, k := range keys {
+		s.lookup[k.Key] = i
+		for _, a := range k.Aliases {
+			s.lookup[a] = i
+		}
+	}
+	return s
+}
+
+// Get returns the SchemaKey for the provided key or alias.
+func (s *Schema) Get(key string) (*SchemaKey, bool) {
+	if s.lookup == nil {
+		return s.find(key)
+	}
+	idx, ok := s.lookup[key]
+	if !ok {
+		return nil, false
+	}
+	return &s.Keys[idx], true
+}
+
+// find searches Keys for the provided key or alias. It is used when the Schema was not created through NewSchema.
+func (s *Schema) find(key string) (*SchemaKey, bool) {
+	for i := range s.Keys {
+		k := &s.Keys[i]
+		if k.Key == key {
+			return k, true
+		}
+		for _, a := range k.Aliases {
+			if a == key {
+				return k, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// parseValue replaces ParserDelegateComparators.ParseValue when parsing with the schema. Comparators are extracted
+// from unquoted values of the keys whose type supports them, regardless of ParserConfig.ComparatorKeys.
+func (s *Schema) parseValue(key, value string, quoted bool) (Comparator, []string) {
+	k, ok := s.Get(key)
+	if quoted || !ok || !k.comparable() {
+		return ComparatorNone, []string{value}
+	}
+	return ParseComparator(value)
+}
+
+// Validate checks the operator against the schema. It returns the canonical key and the normalized operator. The
+// existing operators are required to check MaxValues. Position is included in any returned error.
+func (s *Schema) Validate(existing *Operators, key string, op Operator, position int) (string, Operator, error) {
+	fail := func(base error, value, suggestion string) (string, Operator, error) {
+		return "", op, &SchemaError{
+			Base:       base,
+			Key:        key,
+			Value:      value,
+			Position:   position,
+			Suggestion: suggestion,
+		}
+	}
+
+	k, ok := s.Get(key)
+	if !ok {
+		return fail(ErrSchemaUnknownKey, "", s.suggestKey(key))
+	}
+
+	for _, m := range op.Modifiers {
+		if !k.allowsModifier(m) {
+			return fail(ErrSchemaModifierNotAllowed, string(m), "")
+		}
+	}
+	if !k.allowsComparator(op.Comparator) {
+		return fail(ErrSchemaComparatorNotAllowed, string(op.Comparator), "")
+	}
+
+	values := make([]string, 0, len(op.Values))
+	for _, v := range op.Values {
+		// Ranges may be open-ended.
+		if v == "" && op.Comparator == ComparatorRange {
+			values = append(values, v)
+			continue
+		}
+		normalized, ok := k.normalizeValue(v)
+		if !ok {
+			var suggestion string
+			if k.Type == ValueTypeEnum {
+				suggestion = Suggest(v, k.Enum)
+			}
+			return fail(ErrSchemaInvalidValue, v, suggestion)
+		}
+		values = append(values, normalized)
+	}
+	op.Values = values
+
+	if k.MaxValues > 0 {
+		count := valueCount(op)
+		for _, e := range existing.Values[k.Key] {
+			count += valueCount(e)
+		}
+		if count > k.MaxValues {
+			return fail(ErrSchemaTooManyValues, "", "")
+		}
+	}
+	return k.Key, op, nil
+}
+
+// suggestKey returns the closest key or alias to the provided key, if any is close enough.
+func (s *Schema) suggestKey(key string) string {
+	candidates := make([]string, 0, len(s.Keys))
+	for _, k := range s.Keys {
+		candidates = append(candidates, k.Key)
+		candidates = append(candidates, k.Aliases...)
+	}
+	return Suggest(key, candidates)
+}
+
+// valueCount returns the number of values in the operator. Ranges are considered a single value.
+func valueCount(op Operator) int {
+	if op.Comparator == ComparatorRange {
+		return 1
+	}
+	return len(op.Values)
+}
+
+// Suggest returns the candidate closest to str by edit distance. Candidates which differ by more than a third of their
+// length (minimum 1) are not considered.
+func Suggest(str string, candidates []string) string {
+	var best string
+	bestDist := -1
+	for _, c := range candidates {
+		d := levenshtein(strings.ToLower(str), strings.ToLower(c))
+		maxDist := len(c) / 3
+		if maxDist < 1 {
+			maxDist = 1
+		}
+		if d > maxDist {
+			continue
+		}
+		if bestDist == -1 || d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between the two strings.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = prev[j] + 1
+			if curr[j-1]+1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+			if prev[j-1]+cost < curr[j] {
+				curr[j] = prev[j-1] + cost
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,149 @@
+package operator
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestParser_ParseWithSchema(t *testing.T) {
+	// The schema determines the comparator keys: plays supports comparators, but title does not.
+	p, err := NewParser(&ParserConfig{
+		StringStart:    "\"",
+		StringEnd:      "\"",
+		KeyDelimiter:   ":",
+		ComparatorKeys: []string{"title"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema := NewSchema(
+		SchemaKey{
+			Key:     "status",
+			Aliases: []string{"state"},
+			Type:    ValueTypeEnum,
+			Enum:    []string{"draft", "published"},
+		},
+		SchemaKey{
+			Key:       "id",
+			Type:      ValueTypeUUID,
+			Modifiers: []Modifier{ModifierNot},
+			MaxValues: 1,
+		},
+		SchemaKey{
+			Key:  "plays",
+			Type: ValueTypeInt,
+		},
+		SchemaKey{
+			Key: "title",
+		},
+	)
+
+	tests := []struct {
+		s        string
+		ops      Operators
+		errs     []error
+		position []int
+	}{
+		{
+			s: "state:Draft plays:>10 title:\"hello world\" remainder",
+			ops: Operators{
+				Values: map[string][]Operator{
+					"status": {{Values: []string{"draft"}}},
+					"plays":  {{Values: []string{"10"}, Comparator: ComparatorGt}},
+					"title":  {{Values: []string{"hello world"}}},
+				},
+				Remainders: []Operator{{Values: []string{"remainder"}}},
+			},
+		},
+		{
+			s: "  staus:active plays:abc",
+			ops: Operators{
+				Values: map[string][]Operator{},
+			},
+			errs:     []error{ErrSchemaUnknownKey, ErrSchemaInvalidValue},
+			position: []int{2, 15},
+		},
+		{
+			s: "|id:9d4a8b34-8c51-4a6d-b9ab-4d2f5ee1b6a4 id:9d4a8b34-8c51-4a6d-b9ab-4d2f5ee1b6a4 " +
+				"id:9d4a8b34-8c51-4a6d-b9ab-4d2f5ee1b6a5 title:>5",
+			ops: Operators{
+				Values: map[string][]Operator{
+					"id":    {{Values: []string{"9d4a8b34-8c51-4a6d-b9ab-4d2f5ee1b6a4"}}},
+					"title": {{Values: []string{">5"}}},
+				},
+			},
+			errs:     []error{ErrSchemaModifierNotAllowed, ErrSchemaTooManyValues},
+			position: []int{0, 81},
+		},
+	}
+
+	for i, test := range tests {
+		ops := p.ParseWithSchema(test.s, schema)
+		if !ops.Equals(&test.ops) {
+			t.Errorf("[%d] Operator is not as expected.\n       Expected: %#v\n       Actual: %#v", i, test.ops, ops)
+		}
+		if len(ops.Errors) != len(test.errs) {
+			t.Errorf("[%d] Expected %d errors, got %v", i, len(test.errs), ops.Errors)
+			continue
+		}
+		for j, err := range ops.Errors {
+			if !errors.Is(err, test.errs[j]) {
+				t.Errorf("[%d] Expected error %s, got %s", i, test.errs[j], err)
+			}
+			var serr *SchemaError
+			if errors.As(err, &serr) && serr.Position != test.position[j] {
+				t.Errorf("[%d] Expected position %d, got %d", i, test.position[j], serr.Position)
+			}
+		}
+	}
+
+	// Check for the suggestion.
+	ops := p.ParseWithSchema("staus:active", schema)
+	if len(ops.Errors) != 1 {
+		t.Fatalf("Expecting 1 error, got %v", ops.Errors)
+	}
+	expected := "Key 'staus' at position 0 has been ignored. unknown key. Did you mean 'status'?"
+	if ops.Errors[0].Error() != expected {
+		t.Errorf("Expected error %s, got %s", expected, ops.Errors[0])
+	}
+
+	// Map parsing
+	ops = p.ParseMapWithSchema(url.Values{
+		"status": []string{"published", "archived"},
+	}, schema)
+	if len(ops.Errors) != 1 || !errors.Is(ops.Errors[0], ErrSchemaInvalidValue) {
+		t.Errorf("Expecting invalid value error, got %v", ops.Errors)
+	}
+}
+
+func TestSchema_Literal(t *testing.T) {
+	p, err := NewParser(&ParserConfig{
+		StringStart:  "\"",
+		StringEnd:    "\"",
+		KeyDelimiter: ":",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Schemas which are not created through NewSchema should behave the same.
+	schema := &Schema{
+		Keys: []SchemaKey{
+			{Key: "status", Aliases: []string{"state"}, Type: ValueTypeEnum, Enum: []string{"draft"}},
+		},
+	}
+	ops := p.ParseWithSchema("state:Draft staus:draft", schema)
+	expected := Operators{
+		Values: map[string][]Operator{
+			"status": {{Values: []string{"draft"}}},
+		},
+	}
+	if !ops.Equals(&expected) {
+		t.Errorf("Operator is not as expected.\n       Expected: %#v\n       Actual: %#v", expected, ops)
+	}
+	if len(ops.Errors) != 1 || !errors.Is(ops.Errors[0], ErrSchemaUnknownKey) {
+		t.Errorf("Expecting unknown key error, got %v", ops.Errors)
+	}
+}
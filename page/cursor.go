@@ -0,0 +1,50 @@
+package page
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// Cursor is a position within a keyset paginated listing. It is passed to clients as an opaque string through
+// EncodeCursor and DecodeCursor.
+type Cursor struct {
+	// Values of the keyset columns for the row the cursor points to.
+	Values []interface{} `json:"v"`
+
+	// Backward is true if the cursor retrieves the rows before the position instead of after.
+	Backward bool `json:"b,omitempty"`
+
+	// Sort identifies the keyset the cursor was created with, so that a cursor cannot be reused with a different sort.
+	Sort string `json:"s,omitempty"`
+}
+
+// EncodeCursor converts the cursor into an opaque, URL-safe string.
+func EncodeCursor(c Cursor) (string, error) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// DecodeCursor is the inverse of EncodeCursor. Numbers are decoded as json.Number so that large integers are not
+// converted to floating point. ErrInvalidCursor is returned if the string cannot be decoded.
+func DecodeCursor(str string) (Cursor, error) {
+	var c Cursor
+	b, err := base64.RawURLEncoding.DecodeString(str)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&c); err != nil {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
@@ -10,6 +10,7 @@ const (
 	FieldLimit  = "limit"
 	FieldOffset = "offset"
 	FieldSort   = "sort"
+	FieldCursor = "cursor"
 )
 
 // Count, Limit, Offset, Total are used for setting results of a query. The query performed may change any of these
@@ -29,6 +30,14 @@ type Page struct {
 	// Total is used to tell how many total results are available to query. A value of 0 or less can be considered as
 	// unset.
 	Total int
+
+	// Cursor is the opaque keyset cursor requested. If set, it is used instead of Offset.
+	Cursor string
+
+	// NextCursor and PreviousCursor are set by the query to the cursors for the adjacent pages. An empty value means
+	// there is no such page.
+	NextCursor     string
+	PreviousCursor string
 }
 
 func (p *Page) Clone() *Page {
@@ -38,6 +47,10 @@ func (p *Page) Clone() *Page {
 		Limit:  p.Limit,
 		Count:  p.Count,
 		Total:  p.Total,
+
+		Cursor:         p.Cursor,
+		NextCursor:     p.NextCursor,
+		PreviousCursor: p.PreviousCursor,
 	}
 }
 
@@ -264,9 +277,19 @@ func NewPageFromMap(m map[string][]string) *Page {
 	}
 	p.Limit = p.Int(FieldLimit)
 	p.Offset = p.Int(FieldOffset)
+	p.Cursor = p.Str(FieldCursor)
 	return p
 }
 
+// GetCursor decodes the requested Cursor. Returns false if no cursor was requested.
+func (p *Page) GetCursor() (Cursor, bool, error) {
+	if p.Cursor == "" {
+		return Cursor{}, false, nil
+	}
+	c, err := DecodeCursor(p.Cursor)
+	return c, true, err
+}
+
 func parseBoolStr(str string) bool {
 	check := strings.ToLower(str)
 	switch check {